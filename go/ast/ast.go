@@ -3,6 +3,7 @@ package ast
 import (
 	"bytes"
 	"lang/token"
+	"math/big"
 	"strings"
 )
 
@@ -43,7 +44,7 @@ func (p *Program) String() string {
 }
 
 type LetStatement struct {
-	Token token.Token // the token.LET token
+	Token token.Token // the token.LET, token.CONST or, for fn name() {}, token.FUNCTION token
 	Name  *Identifier
 	Value Expression
 }
@@ -52,6 +53,10 @@ func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLine() int       { return ls.Token.Line }
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
 func (ls *LetStatement) String() string {
+	if ls.Token.Type == token.FUNCTION {
+		// A declaration like fn name() {}, the name is part of the value.
+		return ls.Value.String()
+	}
 	var out bytes.Buffer
 	out.WriteString(ls.TokenLiteral() + " ")
 	out.WriteString(ls.Name.String())
@@ -132,6 +137,17 @@ func (il *IntegerLiteral) TokenLine() int       { return il.Token.Line }
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+// BigIntegerLiteral is an integer literal that does not fit in an int64.
+type BigIntegerLiteral struct {
+	Token token.Token
+	Value *big.Int
+}
+
+func (bl *BigIntegerLiteral) expressionNode()      {}
+func (bl *BigIntegerLiteral) TokenLine() int       { return bl.Token.Line }
+func (bl *BigIntegerLiteral) TokenLiteral() string { return bl.Token.Literal }
+func (bl *BigIntegerLiteral) String() string       { return bl.Token.Literal }
+
 type FloatLiteral struct {
 	Token token.Token
 	Value float64
@@ -213,9 +229,35 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+// ConditionalExpression is the compact if expression cond ? a : b.
+type ConditionalExpression struct {
+	Token       token.Token // The '?' token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode()      {}
+func (ce *ConditionalExpression) TokenLine() int       { return ce.Token.Line }
+func (ce *ConditionalExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *ConditionalExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ce.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(ce.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(ce.Alternative.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 type FunctionLiteral struct {
 	Token      token.Token // The 'fn' token
+	Name       string      // empty for anonymous functions
 	Parameters []*Identifier
+	Defaults   []Expression // default value of each parameter, nil entries for required ones
+	Rest       *Identifier  // collects the remaining arguments in fn(a, ...rest)
 	Body       *BlockStatement
 }
 
@@ -224,18 +266,33 @@ func (fl *FunctionLiteral) TokenLine() int       { return fl.Token.Line }
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
-	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
-	}
 	out.WriteString(fl.TokenLiteral())
+	if fl.Name != "" {
+		out.WriteString(" " + fl.Name)
+	}
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ParametersString(fl.Parameters, fl.Defaults, fl.Rest))
 	out.WriteString(") ")
 	out.WriteString(fl.Body.String())
 	return out.String()
 }
 
+// ParametersString formats a parameter list such as `a, b = 2, ...rest`.
+func ParametersString(params []*Identifier, defaults []Expression, rest *Identifier) string {
+	list := []string{}
+	for i, p := range params {
+		if i < len(defaults) && defaults[i] != nil {
+			list = append(list, p.String()+" = "+defaults[i].String())
+		} else {
+			list = append(list, p.String())
+		}
+	}
+	if rest != nil {
+		list = append(list, "..."+rest.String())
+	}
+	return strings.Join(list, ", ")
+}
+
 type CallExpression struct {
 	Token     token.Token // The '(' token
 	Function  Expression  // Identifier or FunctionLiteral
@@ -266,7 +323,35 @@ type StringLiteral struct {
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLine() int       { return sl.Token.Line }
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
-func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+func (sl *StringLiteral) String() string       { return "\"" + stringEscaper.Replace(sl.Value) + "\"" }
+
+// stringEscaper escapes string literals and the literal parts of an
+// interpolated string so their String() reads back as the same value.
+var stringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`)
+
+type InterpolatedString struct {
+	Token token.Token // the token.STRING_HEAD token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLine() int       { return is.Token.Line }
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	out.WriteString("\"")
+	for _, part := range is.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(stringEscaper.Replace(str.Value))
+			continue
+		}
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	out.WriteString("\"")
+	return out.String()
+}
 
 type ArrayLiteral struct {
 	Token    token.Token // the '[' token
@@ -331,6 +416,7 @@ type MacroLiteral struct {
 	Token      token.Token // The 'macro' token
 	Parameters []*Identifier
 	Body       *BlockStatement
+	Hygienic   bool // renames identifiers bound inside quote on expansion
 }
 
 func (ml *MacroLiteral) expressionNode()      {}
@@ -344,6 +430,9 @@ func (ml *MacroLiteral) String() string {
 		params = append(params, p.String())
 	}
 
+	if ml.Hygienic {
+		out.WriteString("hygienic ")
+	}
 	out.WriteString(ml.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -401,6 +490,29 @@ func (as *AssignExpression) String() string {
 	return out.String()
 }
 
+type IndexAssignExpression struct {
+	Token token.Token // the '=' token
+	Left  Expression
+	Index Expression
+	Value Expression
+}
+
+func (ia *IndexAssignExpression) expressionNode()      {}
+func (ia *IndexAssignExpression) TokenLine() int       { return ia.Token.Line }
+func (ia *IndexAssignExpression) TokenLiteral() string { return ia.Token.Literal }
+func (ia *IndexAssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(ia.Left.String())
+	out.WriteString("[")
+	out.WriteString(ia.Index.String())
+	out.WriteString("] = ")
+	if ia.Value != nil {
+		out.WriteString(ia.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
 type ForStatement struct {
 	Token     token.Token
 	Init      Expression
@@ -439,3 +551,197 @@ func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
 func (bs *BreakStatement) String() string {
 	return bs.Token.Literal + ";"
 }
+
+// ArrayPattern binds the elements of an array in let [a, b, ...rest] = arr.
+type ArrayPattern struct {
+	Token    token.Token // the '[' token
+	Elements []*Identifier
+	Rest     *Identifier // collects the remaining elements, may be nil
+}
+
+func (ap *ArrayPattern) expressionNode()      {}
+func (ap *ArrayPattern) TokenLine() int       { return ap.Token.Line }
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	return "[" + ParametersString(ap.Elements, nil, ap.Rest) + "]"
+}
+
+// HashPattern binds the values of string keys in let {name, age} = person
+// and {"name": n} = person. Names[i] is bound to the value of Keys[i].
+type HashPattern struct {
+	Token token.Token // the '{' token
+	Keys  []*StringLiteral
+	Names []*Identifier
+}
+
+func (hp *HashPattern) expressionNode()      {}
+func (hp *HashPattern) TokenLine() int       { return hp.Token.Line }
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	entries := []string{}
+	for i, name := range hp.Names {
+		entries = append(entries, hp.Keys[i].String()+": "+name.String())
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// DestructuringLetStatement binds the names of an ArrayPattern or a
+// HashPattern.
+type DestructuringLetStatement struct {
+	Token   token.Token // the token.LET or token.CONST token
+	Pattern Expression
+	Value   Expression
+}
+
+func (ds *DestructuringLetStatement) statementNode()       {}
+func (ds *DestructuringLetStatement) TokenLine() int       { return ds.Token.Line }
+func (ds *DestructuringLetStatement) TokenLiteral() string { return ds.Token.Literal }
+func (ds *DestructuringLetStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ds.TokenLiteral() + " ")
+	out.WriteString(ds.Pattern.String())
+	out.WriteString(" = ")
+	if ds.Value != nil {
+		out.WriteString(ds.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// DestructuringAssignExpression assigns to the existing names of a pattern,
+// as in [a, b] = [b, a].
+type DestructuringAssignExpression struct {
+	Token   token.Token // the '=' token
+	Pattern Expression
+	Value   Expression
+}
+
+func (da *DestructuringAssignExpression) expressionNode()      {}
+func (da *DestructuringAssignExpression) TokenLine() int       { return da.Token.Line }
+func (da *DestructuringAssignExpression) TokenLiteral() string { return da.Token.Literal }
+func (da *DestructuringAssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(da.Pattern.String())
+	out.WriteString(" = ")
+	if da.Value != nil {
+		out.WriteString(da.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// MatchExpression evaluates the body of the first arm whose pattern matches
+// the subject: match (x) { 0 => "zero", [a, ...rest] if a > 0 => a, _ => x }
+type MatchExpression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLine() int       { return me.Token.Line }
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+	return "match (" + me.Subject.String() + ") { " + strings.Join(arms, ", ") + " }"
+}
+
+// MatchArm is one `pattern if guard => body` case of a match expression.
+// Patterns are literals, identifiers that bind the value (_ matches anything
+// without binding), and array and hash literals of nested patterns.
+type MatchArm struct {
+	Token   token.Token // the '=>' token
+	Pattern Expression
+	Guard   Expression // may be nil
+	Body    Expression
+}
+
+func (ma *MatchArm) TokenLine() int       { return ma.Token.Line }
+func (ma *MatchArm) TokenLiteral() string { return ma.Token.Literal }
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if " + ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+	return out.String()
+}
+
+// RestPattern collects the remaining elements of an array pattern in a
+// match arm, as in [first, ...rest].
+type RestPattern struct {
+	Token token.Token // the '...' token
+	Name  *Identifier
+}
+
+func (rp *RestPattern) expressionNode()      {}
+func (rp *RestPattern) TokenLine() int       { return rp.Token.Line }
+func (rp *RestPattern) TokenLiteral() string { return rp.Token.Literal }
+func (rp *RestPattern) String() string       { return "..." + rp.Name.String() }
+
+// StructStatement declares a struct type and binds its constructor to the
+// name: struct Point { x, y, fn sum() { self.x + self.y; } }
+type StructStatement struct {
+	Token   token.Token // the 'struct' token
+	Name    *Identifier
+	Fields  []*Identifier
+	Methods []*FunctionLiteral // named functions called with self bound to the struct
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLine() int       { return ss.Token.Line }
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) String() string {
+	members := []string{}
+	for _, field := range ss.Fields {
+		members = append(members, field.String())
+	}
+	for _, method := range ss.Methods {
+		members = append(members, method.String())
+	}
+	return "struct " + ss.Name.String() + " { " + strings.Join(members, ", ") + " }"
+}
+
+// MemberExpression accesses a member of a value with the dot operator, as
+// in point.x.
+type MemberExpression struct {
+	Token  token.Token // the '.' token
+	Object Expression
+	Member *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLine() int       { return me.Token.Line }
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	return "(" + me.Object.String() + "." + me.Member.String() + ")"
+}
+
+// MemberAssignExpression assigns to a struct field, as in point.x = 1.
+type MemberAssignExpression struct {
+	Token  token.Token // the '=' token
+	Object Expression
+	Member *Identifier
+	Value  Expression
+}
+
+func (ma *MemberAssignExpression) expressionNode()      {}
+func (ma *MemberAssignExpression) TokenLine() int       { return ma.Token.Line }
+func (ma *MemberAssignExpression) TokenLiteral() string { return ma.Token.Literal }
+func (ma *MemberAssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(ma.Object.String())
+	out.WriteString(".")
+	out.WriteString(ma.Member.String())
+	out.WriteString(" = ")
+	if ma.Value != nil {
+		out.WriteString(ma.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
@@ -0,0 +1,256 @@
+package ast
+
+// Copy returns a deep copy of node, so that the copy can be rewritten with
+// Modify without touching the original tree.
+func Copy(node Node) Node {
+	if isNilNode(node) {
+		return node
+	}
+	switch node := node.(type) {
+	case *Program:
+		c := *node
+		c.Statements = copyStatements(node.Statements)
+		return &c
+	case *LetStatement:
+		c := *node
+		c.Name = copyIdentifier(node.Name)
+		c.Value = copyExpression(node.Value)
+		return &c
+	case *Identifier:
+		return copyIdentifier(node)
+	case *ReturnStatement:
+		c := *node
+		c.ReturnValue = copyExpression(node.ReturnValue)
+		return &c
+	case *ExpressionStatement:
+		c := *node
+		c.Expression = copyExpression(node.Expression)
+		return &c
+	case *BlockStatement:
+		return copyBlock(node)
+	case *IntegerLiteral:
+		c := *node
+		return &c
+	case *BigIntegerLiteral:
+		c := *node
+		return &c
+	case *FloatLiteral:
+		c := *node
+		return &c
+	case *PrefixExpression:
+		c := *node
+		c.Right = copyExpression(node.Right)
+		return &c
+	case *InfixExpression:
+		c := *node
+		c.Left = copyExpression(node.Left)
+		c.Right = copyExpression(node.Right)
+		return &c
+	case *Boolean:
+		c := *node
+		return &c
+	case *IfExpression:
+		c := *node
+		c.Condition = copyExpression(node.Condition)
+		c.Consequence = copyBlock(node.Consequence)
+		c.Alternative = copyBlock(node.Alternative)
+		return &c
+	case *FunctionLiteral:
+		c := *node
+		c.Parameters = copyIdentifiers(node.Parameters)
+		c.Defaults = copyExpressions(node.Defaults)
+		c.Rest = copyIdentifier(node.Rest)
+		c.Body = copyBlock(node.Body)
+		return &c
+	case *CallExpression:
+		c := *node
+		c.Function = copyExpression(node.Function)
+		c.Arguments = copyExpressions(node.Arguments)
+		return &c
+	case *StringLiteral:
+		c := *node
+		return &c
+	case *InterpolatedString:
+		c := *node
+		c.Parts = copyExpressions(node.Parts)
+		return &c
+	case *ArrayLiteral:
+		c := *node
+		c.Elements = copyExpressions(node.Elements)
+		return &c
+	case *IndexExpression:
+		c := *node
+		c.Left = copyExpression(node.Left)
+		c.Index = copyExpression(node.Index)
+		return &c
+	case *HashLiteral:
+		c := *node
+		c.Pairs = make(map[Expression]Expression, len(node.Pairs))
+		for key, value := range node.Pairs {
+			c.Pairs[copyExpression(key)] = copyExpression(value)
+		}
+		return &c
+	case *MacroLiteral:
+		c := *node
+		c.Parameters = copyIdentifiers(node.Parameters)
+		c.Body = copyBlock(node.Body)
+		return &c
+	case *ErrorLiteral:
+		c := *node
+		return &c
+	case *WhileStatement:
+		c := *node
+		c.Condition = copyExpression(node.Condition)
+		c.Body = copyBlock(node.Body)
+		return &c
+	case *AssignExpression:
+		c := *node
+		c.Name = copyIdentifier(node.Name)
+		c.Value = copyExpression(node.Value)
+		return &c
+	case *IndexAssignExpression:
+		c := *node
+		c.Left = copyExpression(node.Left)
+		c.Index = copyExpression(node.Index)
+		c.Value = copyExpression(node.Value)
+		return &c
+	case *ForStatement:
+		c := *node
+		c.Init = copyExpression(node.Init)
+		c.Condition = copyExpression(node.Condition)
+		c.Update = copyExpression(node.Update)
+		c.Body = copyBlock(node.Body)
+		return &c
+	case *BreakStatement:
+		c := *node
+		return &c
+	case *ArrayPattern:
+		c := *node
+		c.Elements = copyIdentifiers(node.Elements)
+		c.Rest = copyIdentifier(node.Rest)
+		return &c
+	case *HashPattern:
+		c := *node
+		c.Keys = make([]*StringLiteral, len(node.Keys))
+		for i, key := range node.Keys {
+			k := *key
+			c.Keys[i] = &k
+		}
+		c.Names = copyIdentifiers(node.Names)
+		return &c
+	case *DestructuringLetStatement:
+		c := *node
+		c.Pattern = copyExpression(node.Pattern)
+		c.Value = copyExpression(node.Value)
+		return &c
+	case *DestructuringAssignExpression:
+		c := *node
+		c.Pattern = copyExpression(node.Pattern)
+		c.Value = copyExpression(node.Value)
+		return &c
+	case *StructStatement:
+		c := *node
+		c.Name = copyIdentifier(node.Name)
+		c.Fields = copyIdentifiers(node.Fields)
+		if node.Methods != nil {
+			c.Methods = make([]*FunctionLiteral, len(node.Methods))
+			for i, method := range node.Methods {
+				c.Methods[i] = Copy(method).(*FunctionLiteral)
+			}
+		}
+		return &c
+	case *MemberExpression:
+		c := *node
+		c.Object = copyExpression(node.Object)
+		c.Member = copyIdentifier(node.Member)
+		return &c
+	case *MemberAssignExpression:
+		c := *node
+		c.Object = copyExpression(node.Object)
+		c.Member = copyIdentifier(node.Member)
+		c.Value = copyExpression(node.Value)
+		return &c
+	case *ConditionalExpression:
+		c := *node
+		c.Condition = copyExpression(node.Condition)
+		c.Consequence = copyExpression(node.Consequence)
+		c.Alternative = copyExpression(node.Alternative)
+		return &c
+	case *MatchExpression:
+		c := *node
+		c.Subject = copyExpression(node.Subject)
+		c.Arms = make([]*MatchArm, len(node.Arms))
+		for i, arm := range node.Arms {
+			c.Arms[i] = Copy(arm).(*MatchArm)
+		}
+		return &c
+	case *MatchArm:
+		c := *node
+		c.Pattern = copyExpression(node.Pattern)
+		c.Guard = copyExpression(node.Guard)
+		c.Body = copyExpression(node.Body)
+		return &c
+	case *RestPattern:
+		c := *node
+		c.Name = copyIdentifier(node.Name)
+		return &c
+	}
+	return node
+}
+
+func copyIdentifier(ident *Identifier) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	c := *ident
+	return &c
+}
+
+func copyBlock(block *BlockStatement) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	c := *block
+	c.Statements = copyStatements(block.Statements)
+	return &c
+}
+
+func copyExpression(expression Expression) Expression {
+	if expression == nil {
+		return nil
+	}
+	return Copy(expression).(Expression)
+}
+
+func copyStatements(statements []Statement) []Statement {
+	if statements == nil {
+		return nil
+	}
+	copied := make([]Statement, len(statements))
+	for i, s := range statements {
+		copied[i] = Copy(s).(Statement)
+	}
+	return copied
+}
+
+func copyExpressions(expressions []Expression) []Expression {
+	if expressions == nil {
+		return nil
+	}
+	copied := make([]Expression, len(expressions))
+	for i, e := range expressions {
+		copied[i] = copyExpression(e)
+	}
+	return copied
+}
+
+func copyIdentifiers(identifiers []*Identifier) []*Identifier {
+	if identifiers == nil {
+		return nil
+	}
+	copied := make([]*Identifier, len(identifiers))
+	for i, ident := range identifiers {
+		copied[i] = copyIdentifier(ident)
+	}
+	return copied
+}
@@ -0,0 +1,57 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	original := &Program{Statements: []Statement{
+		&LetStatement{
+			Name: &Identifier{Value: "f"},
+			Value: &FunctionLiteral{
+				Parameters: []*Identifier{{Value: "x"}},
+				Body: &BlockStatement{Statements: []Statement{
+					&ExpressionStatement{Expression: &InfixExpression{
+						Left:     &Identifier{Value: "x"},
+						Operator: "+",
+						Right:    &IntegerLiteral{Value: 1},
+					}},
+				}},
+			},
+		},
+		&ForStatement{
+			Condition: &Boolean{Value: true},
+			Body:      &BlockStatement{Statements: []Statement{&BreakStatement{}}},
+		},
+	}}
+
+	copied := Copy(original)
+	if !reflect.DeepEqual(copied, original) {
+		t.Fatalf("copy differs from original. got=%#v", copied)
+	}
+
+	Modify(copied, func(node Node) Node {
+		if ident, ok := node.(*Identifier); ok {
+			ident.Value = "y"
+		}
+		if integer, ok := node.(*IntegerLiteral); ok {
+			integer.Value = 2
+		}
+		return node
+	})
+
+	var idents []string
+	Inspect(original, func(node, parent Node) bool {
+		if ident, ok := node.(*Identifier); ok {
+			idents = append(idents, ident.Value)
+		}
+		if integer, ok := node.(*IntegerLiteral); ok && integer.Value != 1 {
+			t.Errorf("original integer was modified. got=%d", integer.Value)
+		}
+		return true
+	})
+	if !reflect.DeepEqual(idents, []string{"f", "x", "x"}) {
+		t.Errorf("original identifiers were modified. got=%v", idents)
+	}
+}
@@ -0,0 +1,698 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"lang/token"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// jsonNode is the serialized form of a Node. Value holds the payload of
+// literals, Children holds the child nodes (or lists of nodes) by field name.
+type jsonNode struct {
+	Kind     string                     `json:"kind"`
+	Token    *jsonToken                 `json:"token,omitempty"`
+	Line     int                        `json:"line"`
+	Value    interface{}                `json:"value,omitempty"`
+	Operator string                     `json:"operator,omitempty"`
+	Children map[string]json.RawMessage `json:"children,omitempty"`
+}
+
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+	Line    int             `json:"line"`
+}
+
+// MarshalJSON serializes node and all of its children. An empty indent
+// produces compact output.
+func MarshalJSON(node Node, indent string) ([]byte, error) {
+	jn, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	if indent == "" {
+		return json.Marshal(jn)
+	}
+	return json.MarshalIndent(jn, "", indent)
+}
+
+// UnmarshalJSON rebuilds a node serialized by MarshalJSON.
+func UnmarshalJSON(data []byte) (Node, error) {
+	return decodeNode(data)
+}
+
+func encodeNode(node Node) (*jsonNode, error) {
+	jn := &jsonNode{Line: node.TokenLine(), Children: map[string]json.RawMessage{}}
+	var err error
+	child := func(name string, node Node) {
+		if err == nil {
+			jn.Children[name], err = encodeChild(node)
+		}
+	}
+	list := func(name string, nodes []Node) {
+		if err == nil {
+			jn.Children[name], err = encodeList(nodes)
+		}
+	}
+	switch node := node.(type) {
+	case *Program:
+		jn.Kind = "Program"
+		list("statements", statementNodes(node.Statements))
+	case *LetStatement:
+		jn.Kind, jn.Token = "LetStatement", newJSONToken(node.Token)
+		child("name", node.Name)
+		child("value", node.Value)
+	case *Identifier:
+		jn.Kind, jn.Token, jn.Value = "Identifier", newJSONToken(node.Token), node.Value
+	case *ReturnStatement:
+		jn.Kind, jn.Token = "ReturnStatement", newJSONToken(node.Token)
+		child("returnValue", node.ReturnValue)
+	case *ExpressionStatement:
+		jn.Kind, jn.Token = "ExpressionStatement", newJSONToken(node.Token)
+		child("expression", node.Expression)
+	case *BlockStatement:
+		jn.Kind, jn.Token = "BlockStatement", newJSONToken(node.Token)
+		list("statements", statementNodes(node.Statements))
+	case *IntegerLiteral:
+		jn.Kind, jn.Token, jn.Value = "IntegerLiteral", newJSONToken(node.Token), node.Value
+	case *BigIntegerLiteral:
+		jn.Kind, jn.Token, jn.Value = "BigIntegerLiteral", newJSONToken(node.Token), node.Value.String()
+	case *FloatLiteral:
+		jn.Kind, jn.Token = "FloatLiteral", newJSONToken(node.Token)
+		jn.Value = node.Value
+		if math.IsNaN(node.Value) || math.IsInf(node.Value, 0) {
+			jn.Value = strconv.FormatFloat(node.Value, 'g', -1, 64)
+		}
+	case *PrefixExpression:
+		jn.Kind, jn.Token, jn.Operator = "PrefixExpression", newJSONToken(node.Token), node.Operator
+		child("right", node.Right)
+	case *InfixExpression:
+		jn.Kind, jn.Token, jn.Operator = "InfixExpression", newJSONToken(node.Token), node.Operator
+		child("left", node.Left)
+		child("right", node.Right)
+	case *Boolean:
+		jn.Kind, jn.Token, jn.Value = "Boolean", newJSONToken(node.Token), node.Value
+	case *IfExpression:
+		jn.Kind, jn.Token = "IfExpression", newJSONToken(node.Token)
+		child("condition", node.Condition)
+		child("consequence", node.Consequence)
+		child("alternative", node.Alternative)
+	case *FunctionLiteral:
+		jn.Kind, jn.Token = "FunctionLiteral", newJSONToken(node.Token)
+		if node.Name != "" {
+			jn.Value = node.Name
+		}
+		list("parameters", identifierNodes(node.Parameters))
+		if node.Defaults != nil {
+			list("defaults", expressionNodes(node.Defaults))
+		}
+		if node.Rest != nil {
+			child("rest", node.Rest)
+		}
+		child("body", node.Body)
+	case *CallExpression:
+		jn.Kind, jn.Token = "CallExpression", newJSONToken(node.Token)
+		child("function", node.Function)
+		list("arguments", expressionNodes(node.Arguments))
+	case *StringLiteral:
+		jn.Kind, jn.Token, jn.Value = "StringLiteral", newJSONToken(node.Token), node.Value
+	case *InterpolatedString:
+		jn.Kind, jn.Token = "InterpolatedString", newJSONToken(node.Token)
+		list("parts", expressionNodes(node.Parts))
+	case *ArrayLiteral:
+		jn.Kind, jn.Token = "ArrayLiteral", newJSONToken(node.Token)
+		list("elements", expressionNodes(node.Elements))
+	case *IndexExpression:
+		jn.Kind, jn.Token = "IndexExpression", newJSONToken(node.Token)
+		child("left", node.Left)
+		child("index", node.Index)
+	case *HashLiteral:
+		jn.Kind, jn.Token = "HashLiteral", newJSONToken(node.Token)
+		keys, values := sortedPairs(node.Pairs)
+		list("keys", keys)
+		list("values", values)
+	case *MacroLiteral:
+		jn.Kind, jn.Token = "MacroLiteral", newJSONToken(node.Token)
+		if node.Hygienic {
+			jn.Value = true
+		}
+		list("parameters", identifierNodes(node.Parameters))
+		child("body", node.Body)
+	case *ErrorLiteral:
+		jn.Kind, jn.Value = "ErrorLiteral", node.Message
+	case *WhileStatement:
+		jn.Kind, jn.Token = "WhileStatement", newJSONToken(node.Token)
+		child("condition", node.Condition)
+		child("body", node.Body)
+	case *AssignExpression:
+		jn.Kind, jn.Token = "AssignExpression", newJSONToken(node.Token)
+		child("name", node.Name)
+		child("value", node.Value)
+	case *IndexAssignExpression:
+		jn.Kind, jn.Token = "IndexAssignExpression", newJSONToken(node.Token)
+		child("left", node.Left)
+		child("index", node.Index)
+		child("value", node.Value)
+	case *ForStatement:
+		jn.Kind, jn.Token = "ForStatement", newJSONToken(node.Token)
+		child("init", node.Init)
+		child("condition", node.Condition)
+		child("update", node.Update)
+		child("body", node.Body)
+	case *BreakStatement:
+		jn.Kind, jn.Token = "BreakStatement", newJSONToken(node.Token)
+	case *ArrayPattern:
+		jn.Kind, jn.Token = "ArrayPattern", newJSONToken(node.Token)
+		list("elements", identifierNodes(node.Elements))
+		if node.Rest != nil {
+			child("rest", node.Rest)
+		}
+	case *HashPattern:
+		jn.Kind, jn.Token = "HashPattern", newJSONToken(node.Token)
+		keys := make([]Node, len(node.Keys))
+		for i, key := range node.Keys {
+			keys[i] = key
+		}
+		list("keys", keys)
+		list("names", identifierNodes(node.Names))
+	case *DestructuringLetStatement:
+		jn.Kind, jn.Token = "DestructuringLetStatement", newJSONToken(node.Token)
+		child("pattern", node.Pattern)
+		child("value", node.Value)
+	case *DestructuringAssignExpression:
+		jn.Kind, jn.Token = "DestructuringAssignExpression", newJSONToken(node.Token)
+		child("pattern", node.Pattern)
+		child("value", node.Value)
+	case *StructStatement:
+		jn.Kind, jn.Token = "StructStatement", newJSONToken(node.Token)
+		child("name", node.Name)
+		list("fields", identifierNodes(node.Fields))
+		if len(node.Methods) != 0 {
+			methods := make([]Node, len(node.Methods))
+			for i, method := range node.Methods {
+				methods[i] = method
+			}
+			list("methods", methods)
+		}
+	case *MemberExpression:
+		jn.Kind, jn.Token = "MemberExpression", newJSONToken(node.Token)
+		child("object", node.Object)
+		child("member", node.Member)
+	case *MemberAssignExpression:
+		jn.Kind, jn.Token = "MemberAssignExpression", newJSONToken(node.Token)
+		child("object", node.Object)
+		child("member", node.Member)
+		child("value", node.Value)
+	case *ConditionalExpression:
+		jn.Kind, jn.Token = "ConditionalExpression", newJSONToken(node.Token)
+		child("condition", node.Condition)
+		child("consequence", node.Consequence)
+		child("alternative", node.Alternative)
+	case *MatchExpression:
+		jn.Kind, jn.Token = "MatchExpression", newJSONToken(node.Token)
+		child("subject", node.Subject)
+		list("arms", matchArmNodes(node.Arms))
+	case *MatchArm:
+		jn.Kind, jn.Token = "MatchArm", newJSONToken(node.Token)
+		child("pattern", node.Pattern)
+		child("guard", node.Guard)
+		child("body", node.Body)
+	case *RestPattern:
+		jn.Kind, jn.Token = "RestPattern", newJSONToken(node.Token)
+		child("name", node.Name)
+	default:
+		return nil, fmt.Errorf("can not serialize node of type %T", node)
+	}
+	if len(jn.Children) == 0 {
+		jn.Children = nil
+	}
+	return jn, err
+}
+
+func newJSONToken(t token.Token) *jsonToken {
+	return &jsonToken{Type: t.Type, Literal: t.Literal, Line: t.Line}
+}
+
+func encodeChild(node Node) (json.RawMessage, error) {
+	if isNilNode(node) {
+		return json.RawMessage("null"), nil
+	}
+	jn, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jn)
+}
+
+func encodeList(nodes []Node) (json.RawMessage, error) {
+	list := make([]json.RawMessage, 0, len(nodes))
+	for _, node := range nodes {
+		child, err := encodeChild(node)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, child)
+	}
+	return json.Marshal(list)
+}
+
+// isNilNode reports whether node is nil or a typed nil pointer, such as an
+// IfExpression without an Alternative.
+func isNilNode(node Node) bool {
+	switch node := node.(type) {
+	case nil:
+		return true
+	case *Identifier:
+		return node == nil
+	case *BlockStatement:
+		return node == nil
+	}
+	return false
+}
+
+// sortedPairs orders hash literal pairs by their source text so that the
+// output does not depend on map iteration order.
+func sortedPairs(pairs map[Expression]Expression) ([]Node, []Node) {
+	keys := make([]Expression, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	keyNodes := make([]Node, len(keys))
+	valueNodes := make([]Node, len(keys))
+	for i, key := range keys {
+		keyNodes[i] = key
+		valueNodes[i] = pairs[key]
+	}
+	return keyNodes, valueNodes
+}
+
+func statementNodes(statements []Statement) []Node {
+	nodes := make([]Node, len(statements))
+	for i, s := range statements {
+		nodes[i] = s
+	}
+	return nodes
+}
+
+func expressionNodes(expressions []Expression) []Node {
+	nodes := make([]Node, len(expressions))
+	for i, e := range expressions {
+		nodes[i] = e
+	}
+	return nodes
+}
+
+func identifierNodes(identifiers []*Identifier) []Node {
+	nodes := make([]Node, len(identifiers))
+	for i, ident := range identifiers {
+		nodes[i] = ident
+	}
+	return nodes
+}
+
+func matchArmNodes(arms []*MatchArm) []Node {
+	nodes := make([]Node, len(arms))
+	for i, arm := range arms {
+		nodes[i] = arm
+	}
+	return nodes
+}
+
+func decodeNode(data []byte) (Node, error) {
+	if string(data) == "null" {
+		return nil, nil
+	}
+	var jn jsonNode
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&jn); err != nil {
+		return nil, err
+	}
+	d := &nodeDecoder{jn: &jn}
+	var tok token.Token
+	if jn.Token != nil {
+		tok = token.Token{Type: jn.Token.Type, Literal: jn.Token.Literal, Line: jn.Token.Line}
+	}
+	var node Node
+	switch jn.Kind {
+	case "Program":
+		node = &Program{Statements: d.statements("statements")}
+	case "LetStatement":
+		node = &LetStatement{Token: tok, Name: d.identifier("name"), Value: d.expression("value")}
+	case "Identifier":
+		node = &Identifier{Token: tok, Value: d.stringValue()}
+	case "ReturnStatement":
+		node = &ReturnStatement{Token: tok, ReturnValue: d.expression("returnValue")}
+	case "ExpressionStatement":
+		node = &ExpressionStatement{Token: tok, Expression: d.expression("expression")}
+	case "BlockStatement":
+		node = &BlockStatement{Token: tok, Statements: d.statements("statements")}
+	case "IntegerLiteral":
+		node = &IntegerLiteral{Token: tok, Value: d.integerValue()}
+	case "BigIntegerLiteral":
+		node = &BigIntegerLiteral{Token: tok, Value: d.bigIntegerValue()}
+	case "FloatLiteral":
+		node = &FloatLiteral{Token: tok, Value: d.floatValue()}
+	case "PrefixExpression":
+		node = &PrefixExpression{Token: tok, Operator: jn.Operator, Right: d.expression("right")}
+	case "InfixExpression":
+		node = &InfixExpression{Token: tok, Operator: jn.Operator,
+			Left: d.expression("left"), Right: d.expression("right")}
+	case "Boolean":
+		value, ok := jn.Value.(bool)
+		if !ok {
+			d.fail("value of Boolean must be a boolean")
+		}
+		node = &Boolean{Token: tok, Value: value}
+	case "IfExpression":
+		node = &IfExpression{Token: tok, Condition: d.expression("condition"),
+			Consequence: d.block("consequence"), Alternative: d.block("alternative")}
+	case "FunctionLiteral":
+		node = &FunctionLiteral{Token: tok, Name: d.stringValue(), Parameters: d.identifiers("parameters"),
+			Defaults: d.optionalExpressions("defaults"), Rest: d.identifier("rest"), Body: d.block("body")}
+	case "CallExpression":
+		node = &CallExpression{Token: tok, Function: d.expression("function"),
+			Arguments: d.expressions("arguments")}
+	case "StringLiteral":
+		node = &StringLiteral{Token: tok, Value: d.stringValue()}
+	case "InterpolatedString":
+		node = &InterpolatedString{Token: tok, Parts: d.expressions("parts")}
+	case "ArrayLiteral":
+		node = &ArrayLiteral{Token: tok, Elements: d.expressions("elements")}
+	case "IndexExpression":
+		node = &IndexExpression{Token: tok, Left: d.expression("left"), Index: d.expression("index")}
+	case "HashLiteral":
+		keys, values := d.expressions("keys"), d.expressions("values")
+		if len(keys) != len(values) {
+			d.fail("HashLiteral has %d keys but %d values", len(keys), len(values))
+		}
+		pairs := make(map[Expression]Expression)
+		for i := 0; i < len(keys) && i < len(values); i++ {
+			pairs[keys[i]] = values[i]
+		}
+		node = &HashLiteral{Token: tok, Pairs: pairs}
+	case "MacroLiteral":
+		hygienic, _ := jn.Value.(bool)
+		node = &MacroLiteral{Token: tok, Parameters: d.identifiers("parameters"), Body: d.block("body"),
+			Hygienic: hygienic}
+	case "ErrorLiteral":
+		node = &ErrorLiteral{Line: jn.Line, Message: d.stringValue()}
+	case "WhileStatement":
+		node = &WhileStatement{Token: tok, Condition: d.expression("condition"), Body: d.block("body")}
+	case "AssignExpression":
+		node = &AssignExpression{Token: tok, Name: d.identifier("name"), Value: d.expression("value")}
+	case "IndexAssignExpression":
+		node = &IndexAssignExpression{Token: tok, Left: d.expression("left"), Index: d.expression("index"),
+			Value: d.expression("value")}
+	case "ForStatement":
+		node = &ForStatement{Token: tok, Init: d.expression("init"), Condition: d.expression("condition"),
+			Update: d.expression("update"), Body: d.block("body")}
+	case "BreakStatement":
+		node = &BreakStatement{Token: tok}
+	case "ArrayPattern":
+		node = &ArrayPattern{Token: tok, Elements: d.identifiers("elements"), Rest: d.identifier("rest")}
+	case "HashPattern":
+		keys, names := d.stringLiterals("keys"), d.identifiers("names")
+		if len(keys) != len(names) {
+			d.fail("HashPattern has %d keys but %d names", len(keys), len(names))
+		}
+		node = &HashPattern{Token: tok, Keys: keys, Names: names}
+	case "DestructuringLetStatement":
+		node = &DestructuringLetStatement{Token: tok, Pattern: d.expression("pattern"), Value: d.expression("value")}
+	case "DestructuringAssignExpression":
+		node = &DestructuringAssignExpression{Token: tok, Pattern: d.expression("pattern"),
+			Value: d.expression("value")}
+	case "StructStatement":
+		node = &StructStatement{Token: tok, Name: d.identifier("name"), Fields: d.identifiers("fields"),
+			Methods: d.functions("methods")}
+	case "MemberExpression":
+		node = &MemberExpression{Token: tok, Object: d.expression("object"), Member: d.identifier("member")}
+	case "MemberAssignExpression":
+		node = &MemberAssignExpression{Token: tok, Object: d.expression("object"), Member: d.identifier("member"),
+			Value: d.expression("value")}
+	case "ConditionalExpression":
+		node = &ConditionalExpression{Token: tok, Condition: d.expression("condition"),
+			Consequence: d.expression("consequence"), Alternative: d.expression("alternative")}
+	case "MatchExpression":
+		node = &MatchExpression{Token: tok, Subject: d.expression("subject"), Arms: d.matchArms("arms")}
+	case "MatchArm":
+		node = &MatchArm{Token: tok, Pattern: d.expression("pattern"), Guard: d.expression("guard"),
+			Body: d.expression("body")}
+	case "RestPattern":
+		node = &RestPattern{Token: tok, Name: d.identifier("name")}
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", jn.Kind)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return node, nil
+}
+
+// nodeDecoder decodes the value and children of one jsonNode, keeping the
+// first error so that node construction above can stay linear.
+type nodeDecoder struct {
+	jn  *jsonNode
+	err error
+}
+
+func (d *nodeDecoder) fail(format string, a ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf(d.jn.Kind+": "+format, a...)
+	}
+}
+
+func (d *nodeDecoder) child(name string) Node {
+	raw, ok := d.jn.Children[name]
+	if !ok || d.err != nil {
+		return nil
+	}
+	node, err := decodeNode(raw)
+	if err != nil {
+		d.err = err
+		return nil
+	}
+	return node
+}
+
+func (d *nodeDecoder) list(name string) []Node {
+	raw, ok := d.jn.Children[name]
+	if !ok || d.err != nil {
+		return nil
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil {
+		d.fail("%s must be a list: %s", name, err)
+		return nil
+	}
+	nodes := make([]Node, 0, len(list))
+	for _, item := range list {
+		node, err := decodeNode(item)
+		if err != nil {
+			d.err = err
+			return nil
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (d *nodeDecoder) expression(name string) Expression {
+	node := d.child(name)
+	if node == nil {
+		return nil
+	}
+	exp, ok := node.(Expression)
+	if !ok {
+		d.fail("%s must be an expression, got %T", name, node)
+	}
+	return exp
+}
+
+func (d *nodeDecoder) identifier(name string) *Identifier {
+	node := d.child(name)
+	if node == nil {
+		return nil
+	}
+	ident, ok := node.(*Identifier)
+	if !ok {
+		d.fail("%s must be an Identifier, got %T", name, node)
+	}
+	return ident
+}
+
+func (d *nodeDecoder) block(name string) *BlockStatement {
+	node := d.child(name)
+	if node == nil {
+		return nil
+	}
+	block, ok := node.(*BlockStatement)
+	if !ok {
+		d.fail("%s must be a BlockStatement, got %T", name, node)
+	}
+	return block
+}
+
+func (d *nodeDecoder) statements(name string) []Statement {
+	statements := []Statement{}
+	for _, node := range d.list(name) {
+		stmt, ok := node.(Statement)
+		if !ok {
+			d.fail("%s must hold statements, got %T", name, node)
+			return nil
+		}
+		statements = append(statements, stmt)
+	}
+	return statements
+}
+
+func (d *nodeDecoder) expressions(name string) []Expression {
+	expressions := []Expression{}
+	for _, node := range d.list(name) {
+		exp, ok := node.(Expression)
+		if !ok {
+			d.fail("%s must hold expressions, got %T", name, node)
+			return nil
+		}
+		expressions = append(expressions, exp)
+	}
+	return expressions
+}
+
+// optionalExpressions is like expressions but allows null entries and
+// returns nil when the list is missing.
+func (d *nodeDecoder) optionalExpressions(name string) []Expression {
+	if _, ok := d.jn.Children[name]; !ok {
+		return nil
+	}
+	expressions := []Expression{}
+	for _, node := range d.list(name) {
+		if node == nil {
+			expressions = append(expressions, nil)
+			continue
+		}
+		exp, ok := node.(Expression)
+		if !ok {
+			d.fail("%s must hold expressions, got %T", name, node)
+			return nil
+		}
+		expressions = append(expressions, exp)
+	}
+	return expressions
+}
+
+func (d *nodeDecoder) identifiers(name string) []*Identifier {
+	identifiers := []*Identifier{}
+	for _, node := range d.list(name) {
+		ident, ok := node.(*Identifier)
+		if !ok {
+			d.fail("%s must hold identifiers, got %T", name, node)
+			return nil
+		}
+		identifiers = append(identifiers, ident)
+	}
+	return identifiers
+}
+
+func (d *nodeDecoder) stringLiterals(name string) []*StringLiteral {
+	literals := []*StringLiteral{}
+	for _, node := range d.list(name) {
+		literal, ok := node.(*StringLiteral)
+		if !ok {
+			d.fail("%s must hold string literals, got %T", name, node)
+			return nil
+		}
+		literals = append(literals, literal)
+	}
+	return literals
+}
+
+func (d *nodeDecoder) functions(name string) []*FunctionLiteral {
+	var functions []*FunctionLiteral
+	for _, node := range d.list(name) {
+		function, ok := node.(*FunctionLiteral)
+		if !ok {
+			d.fail("%s must hold function literals, got %T", name, node)
+			return nil
+		}
+		functions = append(functions, function)
+	}
+	return functions
+}
+
+func (d *nodeDecoder) matchArms(name string) []*MatchArm {
+	arms := []*MatchArm{}
+	for _, node := range d.list(name) {
+		arm, ok := node.(*MatchArm)
+		if !ok {
+			d.fail("%s must hold match arms, got %T", name, node)
+			return nil
+		}
+		arms = append(arms, arm)
+	}
+	return arms
+}
+
+func (d *nodeDecoder) stringValue() string {
+	if d.jn.Value == nil {
+		return ""
+	}
+	value, ok := d.jn.Value.(string)
+	if !ok {
+		d.fail("value must be a string")
+	}
+	return value
+}
+
+func (d *nodeDecoder) integerValue() int64 {
+	if d.jn.Value == nil {
+		return 0
+	}
+	number, ok := d.jn.Value.(json.Number)
+	if !ok {
+		d.fail("value must be an integer")
+		return 0
+	}
+	value, err := number.Int64()
+	if err != nil {
+		d.fail("value must be an integer")
+	}
+	return value
+}
+
+func (d *nodeDecoder) bigIntegerValue() *big.Int {
+	value, ok := new(big.Int).SetString(d.stringValue(), 10)
+	if !ok {
+		d.fail("value must be a string of digits")
+	}
+	return value
+}
+
+func (d *nodeDecoder) floatValue() float64 {
+	switch value := d.jn.Value.(type) {
+	case nil:
+		return 0
+	case json.Number:
+		f, err := value.Float64()
+		if err != nil {
+			d.fail("value must be a number")
+		}
+		return f
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			d.fail("value must be a number")
+		}
+		return f
+	}
+	d.fail("value must be a number")
+	return 0
+}
@@ -0,0 +1,82 @@
+package ast
+
+// SetLine moves node to the given source line, so that errors raised by
+// generated code point to where it was generated.
+func SetLine(node Node, line int) {
+	switch node := node.(type) {
+	case *LetStatement:
+		node.Token.Line = line
+	case *Identifier:
+		node.Token.Line = line
+	case *ReturnStatement:
+		node.Token.Line = line
+	case *ExpressionStatement:
+		node.Token.Line = line
+	case *BlockStatement:
+		node.Token.Line = line
+	case *IntegerLiteral:
+		node.Token.Line = line
+	case *BigIntegerLiteral:
+		node.Token.Line = line
+	case *FloatLiteral:
+		node.Token.Line = line
+	case *PrefixExpression:
+		node.Token.Line = line
+	case *InfixExpression:
+		node.Token.Line = line
+	case *Boolean:
+		node.Token.Line = line
+	case *IfExpression:
+		node.Token.Line = line
+	case *FunctionLiteral:
+		node.Token.Line = line
+	case *CallExpression:
+		node.Token.Line = line
+	case *StringLiteral:
+		node.Token.Line = line
+	case *InterpolatedString:
+		node.Token.Line = line
+	case *ArrayLiteral:
+		node.Token.Line = line
+	case *IndexExpression:
+		node.Token.Line = line
+	case *HashLiteral:
+		node.Token.Line = line
+	case *MacroLiteral:
+		node.Token.Line = line
+	case *ErrorLiteral:
+		node.Line = line
+	case *WhileStatement:
+		node.Token.Line = line
+	case *AssignExpression:
+		node.Token.Line = line
+	case *IndexAssignExpression:
+		node.Token.Line = line
+	case *ForStatement:
+		node.Token.Line = line
+	case *BreakStatement:
+		node.Token.Line = line
+	case *ArrayPattern:
+		node.Token.Line = line
+	case *HashPattern:
+		node.Token.Line = line
+	case *DestructuringLetStatement:
+		node.Token.Line = line
+	case *DestructuringAssignExpression:
+		node.Token.Line = line
+	case *StructStatement:
+		node.Token.Line = line
+	case *MemberExpression:
+		node.Token.Line = line
+	case *MemberAssignExpression:
+		node.Token.Line = line
+	case *ConditionalExpression:
+		node.Token.Line = line
+	case *MatchExpression:
+		node.Token.Line = line
+	case *MatchArm:
+		node.Token.Line = line
+	case *RestPattern:
+		node.Token.Line = line
+	}
+}
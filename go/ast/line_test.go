@@ -0,0 +1,35 @@
+package ast
+
+import (
+	"lang/token"
+	"testing"
+)
+
+func TestSetLine(t *testing.T) {
+	program := &Program{Statements: []Statement{
+		&ExpressionStatement{
+			Token: token.Token{Line: 3},
+			Expression: &InfixExpression{
+				Token:    token.Token{Line: 3},
+				Left:     &Identifier{Token: token.Token{Line: 3}},
+				Operator: "+",
+				Right:    &ErrorLiteral{Line: 4},
+			},
+		},
+	}}
+
+	Inspect(program, func(node, parent Node) bool {
+		SetLine(node, 9)
+		return true
+	})
+
+	Inspect(program, func(node, parent Node) bool {
+		if _, ok := node.(*Program); ok {
+			return true
+		}
+		if node.TokenLine() != 9 {
+			t.Errorf("%T has wrong line. want=9, got=%d", node, node.TokenLine())
+		}
+		return true
+	})
+}
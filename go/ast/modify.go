@@ -65,6 +65,22 @@ func Modify(node Node, modifier ModifierFunc) Node {
 			}
 			node.Alternative = alternative
 		}
+	case *ConditionalExpression:
+		condition, condOk := Modify(node.Condition, modifier).(Expression)
+		if !condOk {
+			return nil
+		}
+		node.Condition = condition
+		consequence, consOk := Modify(node.Consequence, modifier).(Expression)
+		if !consOk {
+			return nil
+		}
+		node.Consequence = consequence
+		alternative, altOk := Modify(node.Alternative, modifier).(Expression)
+		if !altOk {
+			return nil
+		}
+		node.Alternative = alternative
 	case *BlockStatement:
 		for i := range node.Statements {
 			stmt, ok := Modify(node.Statements[i], modifier).(Statement)
@@ -80,6 +96,11 @@ func Modify(node Node, modifier ModifierFunc) Node {
 		}
 		node.ReturnValue = returnValue
 	case *LetStatement:
+		name, nameOk := Modify(node.Name, modifier).(*Identifier)
+		if !nameOk {
+			return nil
+		}
+		node.Name = name
 		value, ok := Modify(node.Value, modifier).(Expression)
 		if !ok {
 			return nil
@@ -93,11 +114,253 @@ func Modify(node Node, modifier ModifierFunc) Node {
 			}
 			node.Parameters[i] = param
 		}
+		for i := range node.Defaults {
+			if node.Defaults[i] == nil {
+				continue
+			}
+			value, ok := Modify(node.Defaults[i], modifier).(Expression)
+			if !ok {
+				return nil
+			}
+			node.Defaults[i] = value
+		}
+		if node.Rest != nil {
+			rest, ok := Modify(node.Rest, modifier).(*Identifier)
+			if !ok {
+				return nil
+			}
+			node.Rest = rest
+		}
+		body, ok := Modify(node.Body, modifier).(*BlockStatement)
+		if !ok {
+			return nil
+		}
+		node.Body = body
+	case *MacroLiteral:
+		for i := range node.Parameters {
+			param, ok := Modify(node.Parameters[i], modifier).(*Identifier)
+			if !ok {
+				return nil
+			}
+			node.Parameters[i] = param
+		}
+		body, ok := Modify(node.Body, modifier).(*BlockStatement)
+		if !ok {
+			return nil
+		}
+		node.Body = body
+	case *CallExpression:
+		function, ok := Modify(node.Function, modifier).(Expression)
+		if !ok {
+			return nil
+		}
+		node.Function = function
+		for i := range node.Arguments {
+			arg, ok := Modify(node.Arguments[i], modifier).(Expression)
+			if !ok {
+				return nil
+			}
+			node.Arguments[i] = arg
+		}
+	case *AssignExpression:
+		name, nameOk := Modify(node.Name, modifier).(*Identifier)
+		if !nameOk {
+			return nil
+		}
+		node.Name = name
+		value, ok := Modify(node.Value, modifier).(Expression)
+		if !ok {
+			return nil
+		}
+		node.Value = value
+	case *IndexAssignExpression:
+		left, lOk := Modify(node.Left, modifier).(Expression)
+		if !lOk {
+			return nil
+		}
+		node.Left = left
+		index, iOk := Modify(node.Index, modifier).(Expression)
+		if !iOk {
+			return nil
+		}
+		node.Index = index
+		value, ok := Modify(node.Value, modifier).(Expression)
+		if !ok {
+			return nil
+		}
+		node.Value = value
+	case *WhileStatement:
+		condition, condOk := Modify(node.Condition, modifier).(Expression)
+		if !condOk {
+			return nil
+		}
+		node.Condition = condition
 		body, ok := Modify(node.Body, modifier).(*BlockStatement)
 		if !ok {
 			return nil
 		}
 		node.Body = body
+	case *ForStatement:
+		if node.Init != nil {
+			init, ok := Modify(node.Init, modifier).(Expression)
+			if !ok {
+				return nil
+			}
+			node.Init = init
+		}
+		condition, condOk := Modify(node.Condition, modifier).(Expression)
+		if !condOk {
+			return nil
+		}
+		node.Condition = condition
+		if node.Update != nil {
+			update, ok := Modify(node.Update, modifier).(Expression)
+			if !ok {
+				return nil
+			}
+			node.Update = update
+		}
+		body, ok := Modify(node.Body, modifier).(*BlockStatement)
+		if !ok {
+			return nil
+		}
+		node.Body = body
+	case *ArrayPattern:
+		for i := range node.Elements {
+			elem, ok := Modify(node.Elements[i], modifier).(*Identifier)
+			if !ok {
+				return nil
+			}
+			node.Elements[i] = elem
+		}
+		if node.Rest != nil {
+			rest, ok := Modify(node.Rest, modifier).(*Identifier)
+			if !ok {
+				return nil
+			}
+			node.Rest = rest
+		}
+	case *HashPattern:
+		for i := range node.Keys {
+			key, ok := Modify(node.Keys[i], modifier).(*StringLiteral)
+			if !ok {
+				return nil
+			}
+			node.Keys[i] = key
+		}
+		for i := range node.Names {
+			name, ok := Modify(node.Names[i], modifier).(*Identifier)
+			if !ok {
+				return nil
+			}
+			node.Names[i] = name
+		}
+	case *DestructuringLetStatement:
+		pattern, patternOk := Modify(node.Pattern, modifier).(Expression)
+		if !patternOk {
+			return nil
+		}
+		node.Pattern = pattern
+		value, ok := Modify(node.Value, modifier).(Expression)
+		if !ok {
+			return nil
+		}
+		node.Value = value
+	case *DestructuringAssignExpression:
+		pattern, patternOk := Modify(node.Pattern, modifier).(Expression)
+		if !patternOk {
+			return nil
+		}
+		node.Pattern = pattern
+		value, ok := Modify(node.Value, modifier).(Expression)
+		if !ok {
+			return nil
+		}
+		node.Value = value
+	case *StructStatement:
+		name, nameOk := Modify(node.Name, modifier).(*Identifier)
+		if !nameOk {
+			return nil
+		}
+		node.Name = name
+		for i := range node.Fields {
+			field, ok := Modify(node.Fields[i], modifier).(*Identifier)
+			if !ok {
+				return nil
+			}
+			node.Fields[i] = field
+		}
+		for i := range node.Methods {
+			method, ok := Modify(node.Methods[i], modifier).(*FunctionLiteral)
+			if !ok {
+				return nil
+			}
+			node.Methods[i] = method
+		}
+	case *MemberExpression:
+		object, objectOk := Modify(node.Object, modifier).(Expression)
+		if !objectOk {
+			return nil
+		}
+		node.Object = object
+		member, ok := Modify(node.Member, modifier).(*Identifier)
+		if !ok {
+			return nil
+		}
+		node.Member = member
+	case *MemberAssignExpression:
+		object, objectOk := Modify(node.Object, modifier).(Expression)
+		if !objectOk {
+			return nil
+		}
+		node.Object = object
+		member, memberOk := Modify(node.Member, modifier).(*Identifier)
+		if !memberOk {
+			return nil
+		}
+		node.Member = member
+		value, ok := Modify(node.Value, modifier).(Expression)
+		if !ok {
+			return nil
+		}
+		node.Value = value
+	case *MatchExpression:
+		subject, subjectOk := Modify(node.Subject, modifier).(Expression)
+		if !subjectOk {
+			return nil
+		}
+		node.Subject = subject
+		for i := range node.Arms {
+			arm, ok := Modify(node.Arms[i], modifier).(*MatchArm)
+			if !ok {
+				return nil
+			}
+			node.Arms[i] = arm
+		}
+	case *MatchArm:
+		pattern, patternOk := Modify(node.Pattern, modifier).(Expression)
+		if !patternOk {
+			return nil
+		}
+		node.Pattern = pattern
+		if node.Guard != nil {
+			guard, ok := Modify(node.Guard, modifier).(Expression)
+			if !ok {
+				return nil
+			}
+			node.Guard = guard
+		}
+		body, ok := Modify(node.Body, modifier).(Expression)
+		if !ok {
+			return nil
+		}
+		node.Body = body
+	case *RestPattern:
+		name, ok := Modify(node.Name, modifier).(*Identifier)
+		if !ok {
+			return nil
+		}
+		node.Name = name
 	case *ArrayLiteral:
 		for i := range node.Elements {
 			elem, ok := Modify(node.Elements[i], modifier).(Expression)
@@ -106,6 +369,14 @@ func Modify(node Node, modifier ModifierFunc) Node {
 			}
 			node.Elements[i] = elem
 		}
+	case *InterpolatedString:
+		for i := range node.Parts {
+			part, ok := Modify(node.Parts[i], modifier).(Expression)
+			if !ok {
+				return nil
+			}
+			node.Parts[i] = part
+		}
 	case *HashLiteral:
 		newPairs := make(map[Expression]Expression)
 		for key, val := range node.Pairs {
@@ -87,6 +87,10 @@ func TestModify(t *testing.T) {
 				},
 			},
 		},
+		{
+			&ConditionalExpression{Condition: one(), Consequence: one(), Alternative: one()},
+			&ConditionalExpression{Condition: two(), Consequence: two(), Alternative: two()},
+		},
 		{
 			&ReturnStatement{ReturnValue: one()},
 			&ReturnStatement{ReturnValue: two()},
@@ -113,10 +117,92 @@ func TestModify(t *testing.T) {
 				},
 			},
 		},
+		{
+			&FunctionLiteral{
+				Parameters: []*Identifier{{Value: "a"}, {Value: "b"}},
+				Defaults:   []Expression{nil, one()},
+				Body:       &BlockStatement{Statements: []Statement{}},
+			},
+			&FunctionLiteral{
+				Parameters: []*Identifier{{Value: "a"}, {Value: "b"}},
+				Defaults:   []Expression{nil, two()},
+				Body:       &BlockStatement{Statements: []Statement{}},
+			},
+		},
 		{
 			&ArrayLiteral{Elements: []Expression{one(), one()}},
 			&ArrayLiteral{Elements: []Expression{two(), two()}},
 		},
+		{
+			&InterpolatedString{Parts: []Expression{&StringLiteral{Value: "x"}, one()}},
+			&InterpolatedString{Parts: []Expression{&StringLiteral{Value: "x"}, two()}},
+		},
+		{
+			&CallExpression{Function: one(), Arguments: []Expression{one(), two()}},
+			&CallExpression{Function: two(), Arguments: []Expression{two(), two()}},
+		},
+		{
+			&AssignExpression{Name: &Identifier{Value: "x"}, Value: one()},
+			&AssignExpression{Name: &Identifier{Value: "x"}, Value: two()},
+		},
+		{
+			&MacroLiteral{
+				Parameters: []*Identifier{},
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&MacroLiteral{
+				Parameters: []*Identifier{},
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
+		{
+			&WhileStatement{
+				Condition: one(),
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+					},
+				},
+			},
+			&WhileStatement{
+				Condition: two(),
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+					},
+				},
+			},
+		},
+		{
+			&ForStatement{
+				Init:      one(),
+				Condition: one(),
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: one()},
+						&BreakStatement{},
+					},
+				},
+			},
+			&ForStatement{
+				Init:      two(),
+				Condition: two(),
+				Body: &BlockStatement{
+					Statements: []Statement{
+						&ExpressionStatement{Expression: two()},
+						&BreakStatement{},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,139 @@
+package ast
+
+// A Visitor's Visit method is called for each node found by Walk, together
+// with the node's parent (nil for the root). If the returned visitor w is not
+// nil, Walk visits the children of node with w, otherwise they are skipped.
+type Visitor interface {
+	Visit(node Node, parent Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order, visiting every node type
+// including loops, assignments, macros and break statements.
+func Walk(v Visitor, node Node) {
+	walk(v, node, nil)
+}
+
+func walk(v Visitor, node Node, parent Node) {
+	if isNilNode(node) {
+		return
+	}
+	if v = v.Visit(node, parent); v == nil {
+		return
+	}
+	for _, child := range Children(node) {
+		walk(v, child, node)
+	}
+}
+
+type inspector struct {
+	f       func(node Node, parent Node) bool
+	stopped bool
+}
+
+func (in *inspector) Visit(node Node, parent Node) Visitor {
+	if in.stopped {
+		return nil
+	}
+	if !in.f(node, parent) {
+		in.stopped = true
+		return nil
+	}
+	return in
+}
+
+// Inspect traverses an AST in depth-first order calling f(node, parent) for
+// every node. The traversal stops as soon as f returns false.
+func Inspect(node Node, f func(node Node, parent Node) bool) {
+	Walk(&inspector{f: f}, node)
+}
+
+// Children returns the direct children of node in source order, leaving out
+// absent optional parts such as a missing else block.
+func Children(node Node) []Node {
+	var children []Node
+	add := func(nodes ...Node) {
+		for _, n := range nodes {
+			if !isNilNode(n) {
+				children = append(children, n)
+			}
+		}
+	}
+	switch node := node.(type) {
+	case *Program:
+		add(statementNodes(node.Statements)...)
+	case *LetStatement:
+		add(node.Name, node.Value)
+	case *ReturnStatement:
+		add(node.ReturnValue)
+	case *ExpressionStatement:
+		add(node.Expression)
+	case *BlockStatement:
+		add(statementNodes(node.Statements)...)
+	case *PrefixExpression:
+		add(node.Right)
+	case *InfixExpression:
+		add(node.Left, node.Right)
+	case *IfExpression:
+		add(node.Condition, node.Consequence, node.Alternative)
+	case *FunctionLiteral:
+		add(identifierNodes(node.Parameters)...)
+		add(expressionNodes(node.Defaults)...)
+		add(node.Rest, node.Body)
+	case *CallExpression:
+		add(node.Function)
+		add(expressionNodes(node.Arguments)...)
+	case *InterpolatedString:
+		add(expressionNodes(node.Parts)...)
+	case *ArrayLiteral:
+		add(expressionNodes(node.Elements)...)
+	case *IndexExpression:
+		add(node.Left, node.Index)
+	case *HashLiteral:
+		keys, values := sortedPairs(node.Pairs)
+		for i := range keys {
+			add(keys[i], values[i])
+		}
+	case *MacroLiteral:
+		add(identifierNodes(node.Parameters)...)
+		add(node.Body)
+	case *WhileStatement:
+		add(node.Condition, node.Body)
+	case *AssignExpression:
+		add(node.Name, node.Value)
+	case *IndexAssignExpression:
+		add(node.Left, node.Index, node.Value)
+	case *ForStatement:
+		add(node.Init, node.Condition, node.Update, node.Body)
+	case *ArrayPattern:
+		add(identifierNodes(node.Elements)...)
+		add(node.Rest)
+	case *HashPattern:
+		for i := range node.Keys {
+			add(node.Keys[i], node.Names[i])
+		}
+	case *DestructuringLetStatement:
+		add(node.Pattern, node.Value)
+	case *DestructuringAssignExpression:
+		add(node.Pattern, node.Value)
+	case *StructStatement:
+		add(node.Name)
+		add(identifierNodes(node.Fields)...)
+		for _, method := range node.Methods {
+			add(method)
+		}
+	case *MemberExpression:
+		add(node.Object, node.Member)
+	case *MemberAssignExpression:
+		add(node.Object, node.Member, node.Value)
+	case *ConditionalExpression:
+		add(node.Condition, node.Consequence, node.Alternative)
+	case *MatchExpression:
+		add(node.Subject)
+		add(matchArmNodes(node.Arms)...)
+	case *MatchArm:
+		add(node.Pattern, node.Guard, node.Body)
+	case *RestPattern:
+		add(node.Name)
+	}
+	return children
+}
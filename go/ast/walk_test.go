@@ -0,0 +1,138 @@
+package ast
+
+import (
+	"lang/token"
+	"reflect"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	cond := &Boolean{Value: true}
+	brk := &BreakStatement{}
+	assign := &AssignExpression{Name: &Identifier{Value: "i"}, Value: &IntegerLiteral{Value: 1}}
+	body := &BlockStatement{Statements: []Statement{
+		&ExpressionStatement{Expression: assign},
+		brk,
+	}}
+	while := &WhileStatement{Condition: cond, Body: body}
+	macro := &MacroLiteral{
+		Parameters: []*Identifier{{Value: "x"}},
+		Body:       &BlockStatement{},
+	}
+	forStmt := &ForStatement{
+		Condition: &Boolean{Value: false},
+		Body:      &BlockStatement{},
+	}
+	program := &Program{Statements: []Statement{
+		while,
+		&ExpressionStatement{Expression: macro},
+		forStmt,
+	}}
+
+	var kinds []string
+	parents := map[Node]Node{}
+	Inspect(program, func(node, parent Node) bool {
+		kinds = append(kinds, reflect.TypeOf(node).Elem().Name())
+		parents[node] = parent
+		return true
+	})
+
+	expected := []string{
+		"Program",
+		"WhileStatement", "Boolean", "BlockStatement",
+		"ExpressionStatement", "AssignExpression", "Identifier", "IntegerLiteral",
+		"BreakStatement",
+		"ExpressionStatement", "MacroLiteral", "Identifier", "BlockStatement",
+		"ForStatement", "Boolean", "BlockStatement",
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Fatalf("wrong visiting order.\nwant=%v\ngot =%v", expected, kinds)
+	}
+
+	if parents[program] != nil {
+		t.Errorf("root has parent %v", parents[program])
+	}
+	if parents[cond] != while {
+		t.Errorf("wrong parent for while condition. got=%T", parents[cond])
+	}
+	if parents[brk] != body {
+		t.Errorf("wrong parent for break. got=%T", parents[brk])
+	}
+	if parents[assign.Value] != assign {
+		t.Errorf("wrong parent for assigned value. got=%T", parents[assign.Value])
+	}
+}
+
+func TestInspectStopsEarly(t *testing.T) {
+	program := &Program{Statements: []Statement{
+		&ExpressionStatement{Expression: &IntegerLiteral{Value: 1}},
+		&ExpressionStatement{Expression: &IntegerLiteral{Value: 2}},
+	}}
+
+	var visited []int64
+	Inspect(program, func(node, parent Node) bool {
+		if integer, ok := node.(*IntegerLiteral); ok {
+			visited = append(visited, integer.Value)
+			return false
+		}
+		return true
+	})
+
+	if !reflect.DeepEqual(visited, []int64{1}) {
+		t.Errorf("traversal did not stop. visited=%v", visited)
+	}
+}
+
+type skipFunctions struct {
+	visited int
+}
+
+func (s *skipFunctions) Visit(node, parent Node) Visitor {
+	s.visited++
+	if _, ok := node.(*FunctionLiteral); ok {
+		return nil
+	}
+	return s
+}
+
+func TestWalkSkipsChildren(t *testing.T) {
+	fn := &FunctionLiteral{
+		Parameters: []*Identifier{{Value: "x"}},
+		Body: &BlockStatement{Statements: []Statement{
+			&ExpressionStatement{Expression: &Identifier{Value: "x"}},
+		}},
+	}
+	program := &Program{Statements: []Statement{
+		&ExpressionStatement{Expression: fn},
+	}}
+
+	v := &skipFunctions{}
+	Walk(v, program)
+
+	if v.visited != 3 {
+		t.Errorf("wrong number of visited nodes. want=3, got=%d", v.visited)
+	}
+}
+
+func TestChildren(t *testing.T) {
+	ifExpression := &IfExpression{
+		Condition:   &Boolean{Value: true},
+		Consequence: &BlockStatement{},
+	}
+	children := Children(ifExpression)
+	if len(children) != 2 {
+		t.Fatalf("missing else block should be skipped. got=%d children", len(children))
+	}
+
+	hash := &HashLiteral{Pairs: map[Expression]Expression{
+		&StringLiteral{Token: token.Token{Literal: "b"}, Value: "b"}: &IntegerLiteral{Token: token.Token{Literal: "2"}},
+		&StringLiteral{Token: token.Token{Literal: "a"}, Value: "a"}: &IntegerLiteral{Token: token.Token{Literal: "1"}},
+	}}
+	var got []string
+	for _, child := range Children(hash) {
+		got = append(got, child.String())
+	}
+	if !reflect.DeepEqual(got, []string{`"a"`, "1", `"b"`, "2"}) {
+		t.Errorf("wrong hash children. got=%v", got)
+	}
+}
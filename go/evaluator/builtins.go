@@ -1,10 +1,54 @@
 package evaluator
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"lang/ast"
 	"lang/object"
+	"lang/token"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
 )
 
+// osExit is replaced in tests so that `exit` does not stop the test binary.
+var osExit = os.Exit
+
+// stdin backs `input`, `read_line` and `read_all`.
+var stdin = bufio.NewReader(os.Stdin)
+
+// SetInput sets the reader used by the input built-in functions. Callers that
+// read from the same source themselves should pass a shared *bufio.Reader.
+func SetInput(in io.Reader) *bufio.Reader {
+	stdin = bufio.NewReader(in)
+	return stdin
+}
+
+// maxJSONIndent limits the spaces per level of `json_stringify`, like
+// JSON.stringify does, so a huge indent is an error instead of a crash.
+const maxJSONIndent = 10
+
+// stdout receives the output of `print` and the prompt of `input`.
+var stdout io.Writer = os.Stdout
+
+// SetOutput sets the writer used by `print` and `input`, e.g. the REPL's
+// output.
+func SetOutput(out io.Writer) {
+	stdout = out
+}
+
+func readLine() object.Object {
+	line, err := stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return NULL
+	}
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return &object.String{Value: line}
+}
+
 var builtins = map[string]*object.Builtin{
 	"len": {
 		Fn: func(args ...object.Object) object.Object {
@@ -16,7 +60,9 @@ var builtins = map[string]*object.Builtin{
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Pairs))}
 			default:
 				return newError("argument to `len` not supported, got %s",
 					args[0].Type())
@@ -107,6 +153,9 @@ var builtins = map[string]*object.Builtin{
 					args[0].Type())
 			}
 			hash := args[0].(*object.Hash)
+			if hash.Frozen {
+				return newError("can not add to frozen HASH")
+			}
 
 			hashKey, ok := args[1].(object.Hashable)
 
@@ -121,9 +170,275 @@ var builtins = map[string]*object.Builtin{
 	"print": {
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+				fmt.Fprintln(stdout, arg.Inspect())
+			}
+			return NULL
+		},
+	},
+	"json_parse": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			if args[0].Type() != object.STRING_OBJ {
+				return newError("argument to `json_parse` must be STRING, got %s",
+					args[0].Type())
+			}
+			return jsonParse(args[0].(*object.String).Value)
+		},
+	},
+	"json_stringify": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2",
+					len(args))
+			}
+			indent := ""
+			if len(args) == 2 {
+				switch arg := args[1].(type) {
+				case *object.Integer:
+					if arg.Value < 0 {
+						return newError("indent of `json_stringify` must not be negative, got %d",
+							arg.Value)
+					}
+					if arg.Value > maxJSONIndent {
+						return newError("indent of `json_stringify` must be at most %d, got %d",
+							maxJSONIndent, arg.Value)
+					}
+					indent = strings.Repeat(" ", int(arg.Value))
+				case *object.String:
+					indent = arg.Value
+				default:
+					return newError("indent of `json_stringify` must be INTEGER or STRING, got %s",
+						args[1].Type())
+				}
+			}
+			return jsonStringify(args[0], indent)
+		},
+	},
+	"env": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			if args[0].Type() != object.STRING_OBJ {
+				return newError("argument to `env` must be STRING, got %s",
+					args[0].Type())
+			}
+			value, ok := os.LookupEnv(args[0].(*object.String).Value)
+			if !ok {
+				return NULL
+			}
+			return &object.String{Value: value}
+		},
+	},
+	"exit": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1",
+					len(args))
+			}
+			code := 0
+			if len(args) == 1 {
+				if args[0].Type() != object.INTEGER_OBJ {
+					return newError("argument to `exit` must be INTEGER, got %s",
+						args[0].Type())
+				}
+				code = int(args[0].(*object.Integer).Value)
 			}
+			osExit(code)
 			return NULL
 		},
 	},
+	"input": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1",
+					len(args))
+			}
+			if len(args) == 1 {
+				fmt.Fprint(stdout, args[0].Inspect())
+			}
+			return readLine()
+		},
+	},
+	"read_line": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0",
+					len(args))
+			}
+			return readLine()
+		},
+	},
+	"read_all": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return newError("wrong number of arguments. got=%d, want=0",
+					len(args))
+			}
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return newError("could not read input: %s", err.Error())
+			}
+			return &object.String{Value: string(data)}
+		},
+	},
+	"abs": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			return mathAbs(args[0])
+		},
+	},
+	"floor": newRoundingBuiltin("floor", math.Floor),
+	"ceil":  newRoundingBuiltin("ceil", math.Ceil),
+	"round": newRoundingBuiltin("round", math.Round),
+	"sqrt":  newFloatBuiltin("sqrt", math.Sqrt),
+	"sin":   newFloatBuiltin("sin", math.Sin),
+	"cos":   newFloatBuiltin("cos", math.Cos),
+	"tan":   newFloatBuiltin("tan", math.Tan),
+	"log":   newFloatBuiltin("log", math.Log),
+	"exp":   newFloatBuiltin("exp", math.Exp),
+	"pow": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2",
+					len(args))
+			}
+			return mathPow(args[0], args[1])
+		},
+	},
+	"min": {
+		Fn: func(args ...object.Object) object.Object {
+			return mathExtreme("min", "<", args)
+		},
+	},
+	"max": {
+		Fn: func(args ...object.Object) object.Object {
+			return mathExtreme("max", ">", args)
+		},
+	},
+	"int": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			return convertToInteger(args[0])
+		},
+	},
+	"float": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			return convertToFloat(args[0])
+		},
+	},
+	"random": {
+		Fn: func(args ...object.Object) object.Object {
+			return mathRandom(args)
+		},
+	},
+	"seed": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			if args[0].Type() != object.INTEGER_OBJ {
+				return newError("argument to `seed` must be INTEGER, got %s",
+					args[0].Type())
+			}
+			rng.Seed(args[0].(*object.Integer).Value)
+			return NULL
+		},
+	},
+	"type": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			// A struct is typed by its declaration, so Point(1, 2) is a "Point".
+			if s, ok := args[0].(*object.Struct); ok {
+				return &object.String{Value: s.StructType.Name}
+			}
+			return &object.String{Value: string(args[0].Type())}
+		},
+	},
+	"str": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+	"bool": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			return nativeBoolToBooleanObject(isTruthy(args[0]))
+		},
+	},
+	"is_int":      newTypePredicate(object.INTEGER_OBJ, object.BIGINT_OBJ),
+	"is_float":    newTypePredicate(object.FLOAT_OBJ),
+	"is_number":   newTypePredicate(object.INTEGER_OBJ, object.BIGINT_OBJ, object.FLOAT_OBJ),
+	"is_string":   newTypePredicate(object.STRING_OBJ),
+	"is_bool":     newTypePredicate(object.BOOLEAN_OBJ),
+	"is_array":    newTypePredicate(object.ARRAY_OBJ),
+	"is_hash":     newTypePredicate(object.HASH_OBJ),
+	"is_function": newTypePredicate(object.FUNCTION_OBJ, object.BUILTIN_OBJ, object.STRUCT_TYPE_OBJ),
+	"is_null":     newTypePredicate(object.NULL_OBJ),
+	"freeze": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			switch arg := args[0].(type) {
+			case *object.Array:
+				arg.Frozen = true
+			case *object.Hash:
+				arg.Frozen = true
+			case *object.Struct:
+				arg.Frozen = true
+			}
+			return args[0]
+		},
+	},
+	"gensym": {
+		Fn: func(args ...object.Object) object.Object {
+			prefix := "g"
+			switch len(args) {
+			case 0:
+			case 1:
+				str, ok := args[0].(*object.String)
+				if !ok {
+					return newError("argument to `gensym` must be STRING, got %s",
+						args[0].Type())
+				}
+				prefix = str.Value
+			default:
+				return newError("wrong number of arguments. got=%d, want=0 or 1",
+					len(args))
+			}
+			name := gensym(prefix)
+			t := token.Token{Type: token.IDENT, Literal: name}
+			return &object.Quote{Node: &ast.Identifier{Token: t, Value: name}}
+		},
+	},
 }
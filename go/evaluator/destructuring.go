@@ -0,0 +1,116 @@
+package evaluator
+
+import (
+	"fmt"
+	"lang/ast"
+	"lang/object"
+	"lang/token"
+)
+
+type binding struct {
+	name  string
+	value object.Object
+}
+
+func evalDestructuringLetStatement(node *ast.DestructuringLetStatement, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return setLineError(node, val)
+	}
+	if breakObj, ok := val.(*object.Break); ok {
+		return breakError(breakObj.Line)
+	}
+	bindings, err := destructure(node.Pattern, val)
+	if err != nil {
+		return setLineError(node, err)
+	}
+	for _, b := range bindings {
+		if _, ok := env.GetCurrScope(b.name); ok {
+			return &object.Error{Line: node.TokenLine(), Message: fmt.Sprintf("Identifier %s already exists", b.name)}
+		}
+	}
+	for _, b := range bindings {
+		if node.Token.Type == token.CONST {
+			env.SetConst(b.name, b.value)
+		} else {
+			env.Set(b.name, b.value)
+		}
+	}
+	return nil
+}
+
+func evalDestructuringAssignExpression(node *ast.DestructuringAssignExpression, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return setLineError(node, val)
+	}
+	if breakObj, ok := val.(*object.Break); ok {
+		return breakError(breakObj.Line)
+	}
+	bindings, err := destructure(node.Pattern, val)
+	if err != nil {
+		return setLineError(node, err)
+	}
+	envs := make([]*object.Environment, len(bindings))
+	for i, b := range bindings {
+		varEnv, ok := env.GetEnv(b.name)
+		if !ok {
+			return &object.Error{Line: node.TokenLine(), Message: fmt.Sprintf("%s is not defined", b.name)}
+		}
+		if varEnv.IsConst(b.name) {
+			return &object.Error{Line: node.TokenLine(), Message: fmt.Sprintf("can not assign to constant %s", b.name)}
+		}
+		envs[i] = varEnv
+	}
+	for i, b := range bindings {
+		envs[i].Set(b.name, b.value)
+	}
+	return nil
+}
+
+// destructure matches val against an array or hash pattern and returns the
+// value for each name of the pattern. Nothing is bound when the shapes
+// don't match, so an error never leaves half of the names assigned.
+func destructure(pattern ast.Expression, val object.Object) ([]binding, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.ArrayPattern:
+		array, ok := val.(*object.Array)
+		if !ok {
+			return nil, newError("can not destructure %s with an array pattern", val.Type())
+		}
+		want := len(pattern.Elements)
+		got := len(array.Elements)
+		if pattern.Rest == nil && got != want {
+			return nil, newError("wrong number of elements to destructure. got=%d, want=%d", got, want)
+		}
+		if got < want {
+			return nil, newError("wrong number of elements to destructure. got=%d, want=at least %d", got, want)
+		}
+		bindings := []binding{}
+		for i, name := range pattern.Elements {
+			bindings = append(bindings, binding{name.Value, array.Elements[i]})
+		}
+		if pattern.Rest != nil {
+			rest := make([]object.Object, got-want)
+			copy(rest, array.Elements[want:])
+			bindings = append(bindings, binding{pattern.Rest.Value, &object.Array{Elements: rest}})
+		}
+		return bindings, nil
+	case *ast.HashPattern:
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return nil, newError("can not destructure %s with a hash pattern", val.Type())
+		}
+		bindings := []binding{}
+		for i, key := range pattern.Keys {
+			pair, ok := hash.Pairs[(&object.String{Value: key.Value}).HashKey()]
+			if !ok {
+				return nil, newError("key not found in hash: %s", key.Value)
+			}
+			bindings = append(bindings, binding{pattern.Names[i].Value, pair.Value})
+		}
+		return bindings, nil
+	default:
+		return nil, newError("unknown pattern: %T", pattern)
+	}
+}
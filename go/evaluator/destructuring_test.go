@@ -0,0 +1,64 @@
+package evaluator
+
+import (
+	"lang/object"
+	"testing"
+)
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let [a, b] = [1, 2]; a * 10 + b;", 12},
+		{"let [first, ...rest] = [1, 2, 3]; len(rest) * 10 + first;", 21},
+		{"let [first, ...rest] = [1]; len(rest);", 0},
+		{"let [...all] = [1, 2]; all[1];", 2},
+		{`let {name, age} = {"name": "Monkey", "age": 3}; name + str(age);`, "Monkey3"},
+		{`let {x} = {"x": 1, "y": 2}; x;`, 1},
+		{"let a = 1; let b = 2; [a, b] = [b, a]; a * 10 + b;", 21},
+		{"let a = 1; fn() { [a] = [5]; }(); a;", 5},
+		{"let a = 0; let r = 0; [a, ...r] = [1, 2, 3]; a * 10 + len(r);", 12},
+		{`let x = 0; {"x": x} = {"x": 5}; x;`, 5},
+		{`let a = 0; let b = 0; {"k": a, "l": b} = {"k": 1, "l": 2}; a * 10 + b;`, 12},
+		{`let {"full name": name} = {"full name": "Monkey"}; name;`, "Monkey"},
+		{`let x = 0; {"y": x} = {"x": 5};`, "key not found in hash: y"},
+		{"const [a, b] = [1, 2]; a = 3;", "can not assign to constant a"},
+		{"let [a, b] = [1, 2, 3];", "wrong number of elements to destructure. got=3, want=2"},
+		{"let [a, b, ...c] = [1];", "wrong number of elements to destructure. got=1, want=at least 2"},
+		{"let [a] = {};", "can not destructure HASH with an array pattern"},
+		{"let {a} = [1];", "can not destructure ARRAY with a hash pattern"},
+		{`let {a, b} = {"a": 1};`, "key not found in hash: b"},
+		{"let a = 1; let [a] = [2];", "Identifier a already exists"},
+		{"[a] = [1];", "a is not defined"},
+		{"const c = 1; let d = 1; [d, c] = [2, 3];", "can not assign to constant c"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("wrong value for %q. expected=%q, got=%q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, obj.Message)
+				}
+			default:
+				t.Errorf("unexpected object for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestDestructuringBindsNothingOnError(t *testing.T) {
+	env := object.NewEnvironment()
+	Eval(testParseProgram("let x = 1; const c = 1;"), env)
+	Eval(testParseProgram("[x, c] = [2, 3];"), env)
+	x, _ := env.Get("x")
+	testIntegerObject(t, x, 1)
+}
@@ -1,9 +1,13 @@
 package evaluator
 
 import (
+	"bytes"
 	"fmt"
 	"lang/ast"
 	"lang/object"
+	"lang/token"
+	"math"
+	"math/big"
 )
 
 var (
@@ -33,7 +37,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if ok {
 			return &object.Error{Line: node.TokenLine(), Message: fmt.Sprintf("Identifier %s already exists", node.Name.Value)}
 		}
-		env.Set(node.Name.Value, val)
+		if lit, ok := node.Value.(*ast.FunctionLiteral); ok && lit.Name == "" {
+			// let f = fn() {} names the function after its binding.
+			val.(*object.Function).Name = node.Name.Value
+		}
+		if node.Token.Type == token.CONST {
+			env.SetConst(node.Name.Value, val)
+		} else {
+			env.Set(node.Name.Value, val)
+		}
 	case *ast.AssignExpression:
 		val := Eval(node.Value, env)
 		if isError(val) {
@@ -46,7 +58,34 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if !ok {
 			return &object.Error{Line: node.TokenLine(), Message: fmt.Sprintf("%s is not defined", node.Name.Value)}
 		}
+		if varEnv.IsConst(node.Name.Value) {
+			return &object.Error{Line: node.TokenLine(), Message: fmt.Sprintf("can not assign to constant %s", node.Name.Value)}
+		}
 		varEnv.Set(node.Name.Value, val)
+	case *ast.DestructuringLetStatement:
+		return evalDestructuringLetStatement(node, env)
+	case *ast.DestructuringAssignExpression:
+		return evalDestructuringAssignExpression(node, env)
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.MemberExpression:
+		res := evalMemberExpression(node, env)
+		if isError(res) {
+			return setLineError(node, res)
+		}
+		return res
+	case *ast.MemberAssignExpression:
+		res := evalMemberAssignExpression(node, env)
+		if isError(res) {
+			return setLineError(node, res)
+		}
+		return res
+	case *ast.IndexAssignExpression:
+		res := evalIndexAssignExpression(node, env)
+		if isError(res) {
+			return setLineError(node, res)
+		}
+		return res
 	// Expressions
 	case *ast.Identifier:
 		res := evalIdentifier(node, env)
@@ -58,6 +97,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if node.Function.TokenLiteral() == "quote" {
 			return quote(node.Arguments[0], env)
 		}
+		if node.Function.TokenLiteral() == "macroexpand" {
+			return evalMacroexpand(node, env)
+		}
 		function := Eval(node.Function, env)
 		if isError(function) {
 			return setLineError(node, function)
@@ -73,12 +115,16 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return res
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.BigIntegerLiteral:
+		return &object.BigInt{Value: node.Value}
 	case *ast.FloatLiteral:
 		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -91,10 +137,21 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return setLineError(node, res)
 		}
 		return res
+	case *ast.MacroLiteral:
+		// Macros defined in a block are bound at runtime as well, so that
+		// macroexpand can use them.
+		return &object.Macro{Parameters: node.Parameters, Env: env, Body: node.Body, Hygienic: node.Hygienic}
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		fn := &object.Function{Name: node.Name, Parameters: params, Defaults: node.Defaults, Rest: node.Rest, Env: env, Body: body}
+		if node.Name != "" {
+			// A named function can call itself even when it is not bound
+			// by a let statement, e.g. when passed straight to map.
+			fn.Env = object.NewEnclosedEnvironment(env)
+			fn.Env.Set(node.Name, fn)
+		}
+		return fn
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
@@ -135,6 +192,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return res
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.ConditionalExpression:
+		return evalConditionalExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
 		if isError(val) {
@@ -203,6 +264,8 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -219,6 +282,16 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+	max := int64(len(runes) - 1)
+	if idx < 0 || idx > max {
+		return NULL
+	}
+	return &object.String{Value: string(runes[idx])}
+}
+
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 	key, ok := index.(object.Hashable)
@@ -232,6 +305,47 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 	return pair.Value
 }
 
+func evalIndexAssignExpression(node *ast.IndexAssignExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	index := Eval(node.Index, env)
+	if isError(index) {
+		return index
+	}
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	switch left := left.(type) {
+	case *object.Array:
+		if left.Frozen {
+			return newError("can not assign to index of frozen ARRAY")
+		}
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("index operator not supported: %s for ARRAY", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newError("index out of range: %d", idx.Value)
+		}
+		left.Elements[idx.Value] = val
+	case *object.Hash:
+		if left.Frozen {
+			return newError("can not assign to index of frozen HASH")
+		}
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		left.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: val}
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+	return val
+}
+
 func evalHashLiteral(
 	node *ast.HashLiteral,
 	env *object.Environment,
@@ -256,6 +370,24 @@ func evalHashLiteral(
 	return &object.Hash{Pairs: pairs}
 }
 
+func evalInterpolatedString(
+	node *ast.InterpolatedString,
+	env *object.Environment,
+) object.Object {
+	var out bytes.Buffer
+	for _, part := range node.Parts {
+		value := Eval(part, env)
+		if isError(value) {
+			return setLineError(node, value)
+		}
+		if value == nil {
+			value = NULL
+		}
+		out.WriteString(value.Inspect())
+	}
+	return &object.String{Value: out.String()}
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	blockEnv := object.NewEnclosedEnvironment(env)
 	condition := Eval(ie.Condition, blockEnv)
@@ -336,9 +468,23 @@ func evalIdentifier(
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
+	if constant, ok := mathConstants[node.Value]; ok {
+		return constant
+	}
 	return newError("identifier not found: " + node.Value)
 }
 
+func evalConditionalExpression(ce *ast.ConditionalExpression, env *object.Environment) object.Object {
+	condition := Eval(ce.Condition, env)
+	if isError(condition) {
+		return setLineError(ce, condition)
+	}
+	if isTruthy(condition) {
+		return Eval(ce.Consequence, env)
+	}
+	return Eval(ce.Alternative, env)
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -408,7 +554,13 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 	switch rightType {
 	case object.INTEGER_OBJ:
 		value := right.(*object.Integer).Value
+		if value == math.MinInt64 {
+			return newBigInteger(new(big.Int).Neg(big.NewInt(value)))
+		}
 		return &object.Integer{Value: -value}
+	case object.BIGINT_OBJ:
+		value := right.(*object.BigInt).Value
+		return newBigInteger(new(big.Int).Neg(value))
 	case object.FLOAT_OBJ:
 		value := right.(*object.Float).Value
 		return &object.Float{Value: -value}
@@ -425,12 +577,31 @@ func evalIntegerInfixExpression(
 	rightVal := right.(*object.Integer).Value
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		sum := leftVal + rightVal
+		if (sum > leftVal) != (rightVal > 0) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: sum}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		difference := leftVal - rightVal
+		if (difference < leftVal) != (rightVal > 0) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: difference}
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		product := leftVal * rightVal
+		if leftVal != 0 && (product/leftVal != rightVal ||
+			leftVal == -1 && rightVal == math.MinInt64) {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
+		return &object.Integer{Value: product}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntegerInfixExpression(operator, left, right)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
@@ -450,6 +621,51 @@ func evalIntegerInfixExpression(
 	}
 }
 
+func evalBigIntegerInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftVal := getBigIntNumber(left)
+	rightVal := getBigIntNumber(right)
+	switch operator {
+	case "+":
+		return newBigInteger(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return newBigInteger(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return newBigInteger(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return newBigInteger(new(big.Int).Quo(leftVal, rightVal))
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+// newBigInteger returns value as an Integer when it fits in an int64, so a
+// BigInt never holds a value an Integer could.
+func newBigInteger(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
+	}
+	return &object.BigInt{Value: value}
+}
+
 func evalFloatInfixExpression(
 	operator string,
 	left, right object.Object,
@@ -489,12 +705,16 @@ func evalInfixExpression(
 ) object.Object {
 	isLeftInteger := left.Type() == object.INTEGER_OBJ
 	isRightInteger := right.Type() == object.INTEGER_OBJ
-	isLeftNumber := isLeftInteger || left.Type() == object.FLOAT_OBJ
-	isRightNumber := isRightInteger || right.Type() == object.FLOAT_OBJ
+	isLeftWhole := isLeftInteger || left.Type() == object.BIGINT_OBJ
+	isRightWhole := isRightInteger || right.Type() == object.BIGINT_OBJ
+	isLeftNumber := isLeftWhole || left.Type() == object.FLOAT_OBJ
+	isRightNumber := isRightWhole || right.Type() == object.FLOAT_OBJ
 
 	switch {
 	case isLeftInteger && isRightInteger:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isLeftWhole && isRightWhole:
+		return evalBigIntegerInfixExpression(operator, left, right)
 	case isLeftNumber && isRightNumber:
 		return evalFloatInfixExpression(operator, left, right)
 	case operator == "==":
@@ -533,12 +753,23 @@ func evalStringInfixExpression(
 }
 
 func getFloatNumber(number object.Object) float64 {
-	if number.Type() == object.INTEGER_OBJ {
-		return float64(number.(*object.Integer).Value)
+	switch number := number.(type) {
+	case *object.Integer:
+		return float64(number.Value)
+	case *object.BigInt:
+		value, _ := new(big.Float).SetInt(number.Value).Float64()
+		return value
 	}
 	return number.(*object.Float).Value
 }
 
+func getBigIntNumber(number object.Object) *big.Int {
+	if number.Type() == object.INTEGER_OBJ {
+		return big.NewInt(number.(*object.Integer).Value)
+	}
+	return number.(*object.BigInt).Value
+}
+
 func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
@@ -552,11 +783,19 @@ func isError(obj object.Object) bool {
 func applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
+		extendedEnv, err := extendFunctionEnv(fn, args)
+		if err != nil {
+			return err
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
+		if err, ok := evaluated.(*object.Error); ok {
+			err.Trace = append(err.Trace, fmt.Sprintf("%s on line %d", fn.DisplayName(), err.Line))
+		}
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
 		return fn.Fn(args...)
+	case *object.StructType:
+		return newStruct(fn, args)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
@@ -565,12 +804,52 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 func extendFunctionEnv(
 	fn *object.Function,
 	args []object.Object,
-) *object.Environment {
+) (*object.Environment, object.Object) {
+	if err := checkArity(fn, len(args)); err != nil {
+		return nil, err
+	}
 	env := object.NewEnclosedEnvironment(fn.Env)
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		if paramIdx < len(args) {
+			env.Set(param.Value, args[paramIdx])
+			continue
+		}
+		// Defaults are evaluated on each call and can refer to the
+		// parameters before them.
+		value := Eval(fn.Defaults[paramIdx], env)
+		if isError(value) {
+			return nil, value
+		}
+		env.Set(param.Value, value)
+	}
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: rest})
+	}
+	return env, nil
+}
+
+func checkArity(fn *object.Function, got int) *object.Error {
+	required := len(fn.Parameters)
+	for required > 0 && required <= len(fn.Defaults) && fn.Defaults[required-1] != nil {
+		required--
+	}
+	max := len(fn.Parameters)
+	switch {
+	case fn.Rest != nil && got < required:
+		return newError("wrong number of arguments. got=%d, want=at least %d", got, required)
+	case fn.Rest != nil || (got >= required && got <= max):
+		return nil
+	case required == max:
+		return newError("wrong number of arguments. got=%d, want=%d", got, max)
+	case required+1 == max:
+		return newError("wrong number of arguments. got=%d, want=%d or %d", got, required, max)
+	default:
+		return newError("wrong number of arguments. got=%d, want=%d to %d", got, required, max)
 	}
-	return env
 }
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
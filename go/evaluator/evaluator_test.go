@@ -1,9 +1,12 @@
 package evaluator
 
 import (
+	"bytes"
 	"lang/lexer"
 	"lang/object"
 	"lang/parser"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -55,6 +58,61 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func TestEvalBigIntegerExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1;", "9223372036854775808"},
+		{"-9223372036854775807 - 2;", "-9223372036854775809"},
+		{"4294967296 * 4294967296;", "18446744073709551616"},
+		{"-(-9223372036854775807 - 1);", "9223372036854775808"},
+		{"(-9223372036854775807 - 1) / -1;", "9223372036854775808"},
+		{"123456789012345678901234567890;", "123456789012345678901234567890"},
+		{"123456789012345678901234567890 / 10;", "12345678901234567890123456789"},
+		{`
+			let fact = fn(n) { if (n < 2) { return 1; } n * fact(n - 1); };
+			fact(25);
+		`, "15511210043330985984000000"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		result, ok := evaluated.(*object.BigInt)
+		if !ok {
+			t.Errorf("object is not BigInt. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if result.Value.String() != tt.expected {
+			t.Errorf("object has wrong value. got=%s, want=%s",
+				result.Value, tt.expected)
+		}
+	}
+}
+
+func TestBigIntegerDemotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775808 - 1;", 9223372036854775807},
+		{"18446744073709551616 / 4294967296 / 4294967296;", 1},
+		{"9223372036854775808 > 9223372036854775807;", true},
+		{"9223372036854775808 == 9223372036854775808;", true},
+		{"9223372036854775808 + 0.5;", 9223372036854775808.5},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestEvalFloatExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -91,6 +149,33 @@ func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
 	return true
 }
 
+func TestFloatSpecialValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"inf;", "inf"},
+		{"-inf;", "-inf"},
+		{"nan;", "nan"},
+		{"1.0 / 0;", "inf"},
+		{"-1 / 0.0;", "-inf"},
+		{"0.0 / 0;", "nan"},
+		{"inf - inf;", "nan"},
+		{"nan == nan;", "false"},
+		{"nan != nan;", "true"},
+		{"nan < 1;", "false"},
+		{"nan >= 1;", "false"},
+		{"inf > 99999999999999999999;", "true"},
+		{"1 == 1.0;", "true"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -173,6 +258,9 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10; }", nil},
 		{"if (1 > 2) { 10; } else { 20; }", 20},
 		{"if (1 < 2) { 10; } else { 20; }", 10},
+		{"if (1 > 2) { 10; } else if (2 > 1) { 20; } else { 30; }", 20},
+		{"if (1 > 2) { 10; } else if (2 > 3) { 20; } else { 30; }", 30},
+		{"if (1 > 2) { 10; } else if (2 > 3) { 20; }", nil},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -185,6 +273,36 @@ func TestIfElseExpressions(t *testing.T) {
 		}
 	}
 }
+func TestConditionalExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true ? 1 : 2;", 1},
+		{"false ? 1 : 2;", 2},
+		{"1 > 2 ? 1 : 3 > 2 ? 2 : 3;", 2},
+		{"let x = 0; true ? 1 : x = 5; x;", 0},
+		{"let f = fn(n) { n < 2 ? n : f(n - 1) + f(n - 2); }; f(10);", 55},
+		{"1 + true ? 1 : 2;", "type mismatch: INTEGER + BOOLEAN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func testNullObject(t *testing.T, obj object.Object) bool {
 	if obj != NULL {
 		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
@@ -284,6 +402,14 @@ func TestErrorHandling(t *testing.T) {
 			"break;",
 			"can not use break outside of loops",
 		},
+		{
+			"1 / 0;",
+			"division by zero",
+		},
+		{
+			"123456789012345678901234567890 / 0;",
+			"division by zero",
+		},
 		{
 			"fn(){ break; 1; }();",
 			"can not use break outside of loops",
@@ -356,6 +482,146 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestDefaultAndRestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = fn(a, b = 2) { a + b; }; f(1);", 3},
+		{"let f = fn(a, b = 2) { a + b; }; f(1, 5);", 6},
+		{"let f = fn(a, b = a * 10) { b; }; f(3);", 30},
+		{"let f = fn(...rest) { len(rest); }; f();", 0},
+		{"let f = fn(a, ...rest) { rest[1]; }; f(1, 2, 3);", 3},
+		{"let f = fn(a = 1, ...rest) { a + len(rest); }; f();", 1},
+		{"let f = fn(a = missing) { a; }; f();", "identifier not found: missing"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestFunctionArity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(x) { x; }();", "wrong number of arguments. got=0, want=1"},
+		{"fn(x) { x; }(1, 2);", "wrong number of arguments. got=2, want=1"},
+		{"fn(x, y = 1) { x; }(1, 2, 3);", "wrong number of arguments. got=3, want=1 or 2"},
+		{"fn(x, y = 1, z = 2) { x; }();", "wrong number of arguments. got=0, want=1 to 3"},
+		{"fn(x, y, ...z) { x; }(1);", "wrong number of arguments. got=1, want=at least 2"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestNamedFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"fn add(a, b) { a + b; } add(1, 2);", 3},
+		{"fn fact(n) { if (n < 2) { 1; } else { n * fact(n - 1); } } fact(5);", 120},
+		{"let apply = fn(f, x) { f(x); }; apply(fn sum(n) { if (n == 0) { 0; } else { n + sum(n - 1); } }, 4);", 10},
+		{"let f = fn g() { 1; }; g;", "identifier not found: g"},
+		{"fn f() { 1; } let f = 2;", "Identifier f already exists"},
+		{"fn f(x) { x * 2; }(5);", 10},
+		{"fn f() { [1, 2]; }()[1];", 2},
+		{"fn f() { 1; }(); f;", "identifier not found: f"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestFunctionInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn add(a, b) { a + b; } add;", "<fn add/2>"},
+		{"let inc = fn(x) { x + 1; }; inc;", "<fn inc/1>"},
+		{"let f = fn g(x) { x; }; f;", "<fn g/1>"},
+		{"fn() { 1; };", "<fn/0>"},
+		{"let f = fn() { 1; }; let g = f; g;", "<fn f/0>"},
+		{"fn greet(name, greeting = \"hi\") { greeting; } greet;", "<fn greet/1..2>"},
+		{"fn(a = 1, b = 2) { a; };", "<fn/0..2>"},
+		{"fn sum(first, ...rest) { first; } sum;", "<fn sum/1+>"},
+		{"fn(a, b = 1, ...rest) { a; };", "<fn/1+>"},
+		{"fn(...rest) { rest; };", "<fn/0+>"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect. expected=%q, got=%q", tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestErrorTrace(t *testing.T) {
+	input := `fn inner(x) {
+	x + true;
+}
+let outer = fn(x) {
+	inner(x);
+};
+fn(x) { outer(x); }(1);`
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := []string{"inner on line 2", "outer on line 5", "anonymous function on line 7"}
+	if len(errObj.Trace) != len(expected) {
+		t.Fatalf("wrong trace length. want=%d, got=%d (%q)", len(expected), len(errObj.Trace), errObj.Trace)
+	}
+	for i, frame := range expected {
+		if errObj.Trace[i] != frame {
+			t.Errorf("wrong frame %d. want=%q, got=%q", i, frame, errObj.Trace[i])
+		}
+	}
+	if errObj.Line != 7 {
+		t.Errorf("wrong error line. want=7, got=%d", errObj.Line)
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 		let newAdder = fn(x) {
@@ -390,6 +656,37 @@ func TestStringConcatenation(t *testing.T) {
 	}
 }
 
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let a = 1; let b = 2.5; "sum is ${a + b}";`, "sum is 3.5"},
+		{`"${[1, "x"]} ${true} ${len("abc")}";`, "[1, x] true 3"},
+		{`let name = "Monkey"; "hi ${"dear ${name}"}!";`, "hi dear Monkey!"},
+		{`"${if (false) { 1; }}";`, "null"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+	evaluated := testEval(`"bad ${1 + true}";`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
 func TestBuiltinFunctions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -398,6 +695,8 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("");`, 0},
 		{`len("four");`, 4},
 		{`len("hello world");`, 11},
+		{`len("héllo");`, 5},
+		{`len("\u{1F600}");`, 1},
 		{`len(1);`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two");`, "wrong number of arguments. got=2, want=1"},
 	}
@@ -421,6 +720,84 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestEnvBuiltin(t *testing.T) {
+	t.Setenv("MONKEY_TEST_VAR", "banana")
+	evaluated := testEval(`env("MONKEY_TEST_VAR");`)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "banana" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+	testNullObject(t, testEval(`env("MONKEY_TEST_UNDEFINED_VAR");`))
+}
+
+func TestExitBuiltin(t *testing.T) {
+	defer func() { osExit = os.Exit }()
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`exit();`, 0},
+		{`exit(3);`, 3},
+	}
+	for _, tt := range tests {
+		code := -1
+		osExit = func(c int) { code = c }
+		testEval(tt.input)
+		if code != tt.expected {
+			t.Errorf("wrong exit code. expected=%d, got=%d", tt.expected, code)
+		}
+	}
+}
+
+func TestInputBuiltins(t *testing.T) {
+	defer SetInput(os.Stdin)
+	tests := []struct {
+		input    string
+		stdin    string
+		expected interface{}
+	}{
+		{`read_line();`, "first\nsecond\n", "first"},
+		{`read_line(); read_line();`, "first\r\nsecond", "second"},
+		{`read_line();`, "", nil},
+		{`read_line(); read_line();`, "only\n", nil},
+		{`input();`, "answer\n", "answer"},
+		{`read_line(); read_all();`, "a\nb\nc\n", "b\nc\n"},
+		{`read_all();`, "", ""},
+	}
+	for _, tt := range tests {
+		SetInput(strings.NewReader(tt.stdin))
+		evaluated := testEval(tt.input)
+		expected, ok := tt.expected.(string)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+		}
+	}
+}
+
+func TestOutputBuiltins(t *testing.T) {
+	defer SetInput(os.Stdin)
+	defer SetOutput(os.Stdout)
+	var out bytes.Buffer
+	SetOutput(&out)
+	SetInput(strings.NewReader("Monkey\n"))
+	testEval(`let name = input("name? "); print("hi " + name, 1);`)
+	if out.String() != "name? hi Monkey\n1\n" {
+		t.Errorf("wrong output. got=%q", out.String())
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3];"
 	evaluated := testEval(input)
@@ -494,6 +871,35 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"héllo"[1];`, "é"},
+		{`"héllo"[4];`, "o"},
+		{`let s = "日本語"; s[len(s) - 1];`, "語"},
+		{`"héllo"[5];`, nil},
+		{`"héllo"[-1];`, nil},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		expected, ok := tt.expected.(string)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+		}
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
 	{
@@ -554,6 +960,10 @@ func TestHashIndexExpressions(t *testing.T) {
 			`{5: 5}[5];`,
 			5,
 		},
+		{
+			`{99999999999999999999: 5}[99999999999999999998 + 1];`,
+			5,
+		},
 		{
 			`{true: 5}[true];`,
 			5,
@@ -562,6 +972,26 @@ func TestHashIndexExpressions(t *testing.T) {
 			`{false: 5}[false];`,
 			5,
 		},
+		{
+			`{1.5: 5}[1.5];`,
+			5,
+		},
+		{
+			`{1: 5}[1.0];`,
+			5,
+		},
+		{
+			`{2.0: 5}[2];`,
+			5,
+		},
+		{
+			`{1.5: 5}[1];`,
+			nil,
+		},
+		{
+			`{nan: 5}[0.0 / 0.0];`,
+			5,
+		},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -597,6 +1027,8 @@ func TestVariableScopes(t *testing.T) {
 		{"let x = 0; if(!x){ let x = 10; }; x;", 0},
 		{"let x = 0; let y = 0; while(!y){ let x = 10; y = y + 1;  } x;", 0},
 		{"let x = 0; let y = 0; for(;!y;){ let x = 10; y = y + 1;  } x;", 0},
+		{"let x = 0; if(true){ if(true){ x = 3; } }; x;", 3},
+		{"let x = 0; let f = fn() { fn() { x = 4; }(); }; f(); x;", 4},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -604,6 +1036,86 @@ func TestVariableScopes(t *testing.T) {
 	}
 }
 
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"const x = 5; x;", 5},
+		{"const x = 5; let f = fn() { let x = 1; x = 2; x; }; f() + x;", 7},
+		{"const x = 5; x = 6;", "can not assign to constant x"},
+		{"const x = 5; if (true) { x = 6; }", "can not assign to constant x"},
+		{"const x = 5; let x = 6;", "Identifier x already exists"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestConstErrorLine(t *testing.T) {
+	evaluated := testEval("const x = 5;\nlet y = 1;\nx = 6;")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Line != 3 {
+		t.Errorf("wrong error line. want=3, got=%d", errObj.Line)
+	}
+}
+
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = [1, 2, 3]; a[1] = 5; a[1];", 5},
+		{"let a = [1, 2, 3]; let b = a; b[0] = 9; a[0];", 9},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] + h["b"];`, 3},
+		{"let a = [1]; a[0] = 2;", 2},
+		{"let a = [1]; a[1] = 2;", "index out of range: 1"},
+		{"let a = [1]; a[\"x\"] = 2;", "index operator not supported: STRING for ARRAY"},
+		{"let h = {}; h[fn(x) { x; }] = 2;", "unusable as hash key: FUNCTION"},
+		{"let s = \"ab\"; s[0] = \"c\";", "index assignment not supported: STRING"},
+		{"let a = freeze([1, 2]); a[0] = 3;", "can not assign to index of frozen ARRAY"},
+		{`let h = freeze({"a": 1}); h["a"] = 3;`, "can not assign to index of frozen HASH"},
+		{`let h = freeze({"a": 1}); add(h, "b", 2);`, "can not add to frozen HASH"},
+		{"let a = freeze([1, 2]); len(push(a, 3));", 3},
+		{"freeze(5);", 5},
+		{"const a = freeze([1]); a[0];", 1},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func TestWhileStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -0,0 +1,131 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"lang/object"
+	"math"
+	"math/big"
+	"strings"
+)
+
+func jsonParse(input string) object.Object {
+	decoder := json.NewDecoder(strings.NewReader(input))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return newError("invalid JSON: %s", err.Error())
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return newError("invalid JSON: unexpected data after top-level value")
+	}
+	return convertJSONToObject(value)
+}
+
+func convertJSONToObject(value interface{}) object.Object {
+	switch value := value.(type) {
+	case nil:
+		return NULL
+	case bool:
+		return nativeBoolToBooleanObject(value)
+	case string:
+		return &object.String{Value: value}
+	case json.Number:
+		if !strings.ContainsAny(value.String(), ".eE") {
+			if integer, ok := new(big.Int).SetString(value.String(), 10); ok {
+				return newBigInteger(integer)
+			}
+		}
+		float, err := value.Float64()
+		if err != nil {
+			return newError("invalid JSON: could not parse %q as number", value.String())
+		}
+		return &object.Float{Value: float}
+	case []interface{}:
+		elements := make([]object.Object, 0, len(value))
+		for _, el := range value {
+			element := convertJSONToObject(el)
+			if isError(element) {
+				return element
+			}
+			elements = append(elements, element)
+		}
+		return &object.Array{Elements: elements}
+	case map[string]interface{}:
+		pairs := make(map[object.HashKey]object.HashPair)
+		for k, v := range value {
+			key := &object.String{Value: k}
+			val := convertJSONToObject(v)
+			if isError(val) {
+				return val
+			}
+			pairs[key.HashKey()] = object.HashPair{Key: key, Value: val}
+		}
+		return &object.Hash{Pairs: pairs}
+	default:
+		return newError("invalid JSON: unsupported value %T", value)
+	}
+}
+
+func jsonStringify(obj object.Object, indent string) object.Object {
+	value, errObj := convertObjectToJSON(obj)
+	if errObj != nil {
+		return errObj
+	}
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(value); err != nil {
+		return newError("could not serialize to JSON: %s", err.Error())
+	}
+	return &object.String{Value: strings.TrimSuffix(out.String(), "\n")}
+}
+
+func convertObjectToJSON(obj object.Object) (interface{}, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Null:
+		return nil, nil
+	case *object.Boolean:
+		return obj.Value, nil
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.BigInt:
+		return json.Number(obj.Value.String()), nil
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return nil, newError("can not serialize %s to JSON", obj.Inspect())
+		}
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.Array:
+		elements := make([]interface{}, 0, len(obj.Elements))
+		for _, el := range obj.Elements {
+			element, err := convertObjectToJSON(el)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		return elements, nil
+	case *object.Hash:
+		pairs := make(map[string]interface{}, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return nil, newError("JSON object keys must be STRING, got %s",
+					pair.Key.Type())
+			}
+			value, err := convertObjectToJSON(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			pairs[key.Value] = value
+		}
+		return pairs, nil
+	default:
+		return nil, newError("can not serialize %s to JSON", obj.Type())
+	}
+}
@@ -0,0 +1,88 @@
+package evaluator
+
+import (
+	"lang/object"
+	"testing"
+)
+
+func TestJSONParse(t *testing.T) {
+	input := `{"name": "Monkey", "age": 3, "height": 1.5, "tags": [true, null]}`
+	evaluated := jsonParse(input)
+	hash, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("object is not Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(hash.Pairs) != 4 {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(hash.Pairs))
+	}
+	name := hash.Pairs[(&object.String{Value: "name"}).HashKey()].Value
+	if str, ok := name.(*object.String); !ok || str.Value != "Monkey" {
+		t.Errorf("name is not \"Monkey\". got=%T (%+v)", name, name)
+	}
+	testIntegerObject(t, hash.Pairs[(&object.String{Value: "age"}).HashKey()].Value, 3)
+	testFloatObject(t, hash.Pairs[(&object.String{Value: "height"}).HashKey()].Value, 1.5)
+	tags, ok := hash.Pairs[(&object.String{Value: "tags"}).HashKey()].Value.(*object.Array)
+	if !ok || len(tags.Elements) != 2 {
+		t.Fatalf("tags is not Array with 2 elements. got=%+v", tags)
+	}
+	testBooleanObject(t, tags.Elements[0], true)
+	testNullObject(t, tags.Elements[1])
+}
+
+func TestJSONStringify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json_stringify(5);`, `5`},
+		{`json_stringify(2.5);`, `2.5`},
+		{`json_stringify("a<b");`, `"a<b"`},
+		{`json_stringify([1, true, "x", if (false) { 1; }]);`, `[1,true,"x",null]`},
+		{`json_stringify({"b": 2, "a": [1]});`, `{"a":[1],"b":2}`},
+		{`json_stringify({"a": [1]}, 2);`, "{\n  \"a\": [\n    1\n  ]\n}"},
+		{`json_stringify({"a": 1}, "\t");`, "{\n\t\"a\": 1\n}"},
+		{`json_stringify([1], 10);`, "[\n          1\n]"},
+		{`json_stringify(json_parse("[1, 2.5, false, null]"));`, `[1,2.5,false,null]`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`json_parse("[1, 2");`, "invalid JSON: unexpected EOF"},
+		{`json_parse("1 2");`, "invalid JSON: unexpected data after top-level value"},
+		{`json_parse(1);`, "argument to `json_parse` must be STRING, got INTEGER"},
+		{`json_stringify(fn(x) { x; });`, "can not serialize FUNCTION to JSON"},
+		{`json_stringify([len]);`, "can not serialize BUILTIN to JSON"},
+		{`json_stringify({1: "one"});`, "JSON object keys must be STRING, got INTEGER"},
+		{`json_stringify([1], 4000000000000);`, "indent of `json_stringify` must be at most 10, got 4000000000000"},
+		{`json_stringify([1], -1);`, "indent of `json_stringify` must not be negative, got -1"},
+		{`json_stringify(1, true);`, "indent of `json_stringify` must be INTEGER or STRING, got BOOLEAN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)",
+				evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -1,29 +1,164 @@
 package evaluator
 
 import (
+	"fmt"
 	"lang/ast"
 	"lang/object"
+	"strings"
 )
 
+// DefineMacros moves the macro definitions at the top level of program
+// into env. A macro can only be called after its definition.
 func DefineMacros(program *ast.Program, env *object.Environment) {
-	definitions := []int{}
+	program.Statements = defineMacros(program.Statements, env, false)
+}
+
+// DefineMacroLibrary defines the macros of a parsed macro library in env, so
+// that one set of macros can be shared between programs. A library may only
+// contain macro definitions.
+func DefineMacroLibrary(program *ast.Program, env *object.Environment) error {
+	DefineMacros(program, env)
+	if len(program.Statements) != 0 {
+		return fmt.Errorf("line %d: macro libraries may only contain macro definitions",
+			program.Statements[0].TokenLine())
+	}
+	return nil
+}
 
-	for i, statement := range program.Statements {
+// defineMacros adds the macro definitions among statements to env and
+// returns the remaining statements, including the definitions if keep is set.
+// Calls to a macro that is defined later in the same list are replaced by an
+// error.
+func defineMacros(
+	statements []ast.Statement,
+	env *object.Environment,
+	keep bool,
+) []ast.Statement {
+	later := map[string]bool{}
+	for _, statement := range statements {
+		if isMacroDefinition(statement) {
+			later[statement.(*ast.LetStatement).Name.Value] = true
+		}
+	}
+
+	remaining := []ast.Statement{}
+	for _, statement := range statements {
 		if isMacroDefinition(statement) {
 			addMacro(statement, env)
-			definitions = append(definitions, i)
+			delete(later, statement.(*ast.LetStatement).Name.Value)
+			if keep {
+				remaining = append(remaining, statement)
+			}
+			continue
+		}
+		if len(later) != 0 {
+			statement, _ = markEarlyMacroCalls(statement, later, env).(ast.Statement)
 		}
+		remaining = append(remaining, statement)
 	}
+	return remaining
+}
 
-	for i := len(definitions) - 1; i >= 0; i = i - 1 {
-		definitionIndex := definitions[i]
-		program.Statements = append(
-			program.Statements[:definitionIndex],
-			program.Statements[definitionIndex+1:]...,
-		)
+func markEarlyMacroCalls(node ast.Node, later map[string]bool, env *object.Environment) ast.Node {
+	return ast.Modify(node, func(node ast.Node) ast.Node {
+		callExpression, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+		identifier, ok := callExpression.Function.(*ast.Identifier)
+		if !ok || !later[identifier.Value] {
+			return node
+		}
+		if _, ok := isMacroCall(callExpression, env); ok {
+			return node
+		}
+		return &ast.ErrorLiteral{
+			Line:    callExpression.TokenLine(),
+			Message: "macro used before definition: " + identifier.Value,
+		}
+	})
+}
+
+// defineScopedMacros defines the macros of every nested block in a new
+// scope of env and records the scope each call expression belongs to. Calls
+// inside quote, a macro body or the arguments of a macro call are recorded
+// with a nil scope, unless they are unquoted inside quote. The definitions stay in the block, so that evaluating them
+// binds the macros for macroexpand at runtime.
+func defineScopedMacros(
+	node ast.Node,
+	env *object.Environment,
+	scopes map[*ast.CallExpression]*object.Environment,
+) {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		if hasMacroDefinition(node.Statements) {
+			env = object.NewEnclosedEnvironment(env)
+			node.Statements = defineMacros(node.Statements, env, true)
+		}
+	case *ast.MacroLiteral:
+		ast.Inspect(node.Body, func(node, parent ast.Node) bool {
+			if call, ok := node.(*ast.CallExpression); ok {
+				scopes[call] = nil
+			}
+			return true
+		})
+		return
+	case *ast.CallExpression:
+		scopes[node] = env
+		if isQuoteCall(node) {
+			for _, arg := range node.Arguments {
+				markQuotedCalls(arg, env, scopes)
+			}
+			return
+		}
+		if _, ok := isMacroCall(node, env); ok {
+			for _, arg := range node.Arguments {
+				ast.Inspect(arg, func(node, parent ast.Node) bool {
+					if call, ok := node.(*ast.CallExpression); ok {
+						scopes[call] = nil
+					}
+					return true
+				})
+			}
+			return
+		}
+	}
+	for _, child := range ast.Children(node) {
+		defineScopedMacros(child, env, scopes)
 	}
 }
 
+func markQuotedCalls(
+	node ast.Node,
+	env *object.Environment,
+	scopes map[*ast.CallExpression]*object.Environment,
+) {
+	if isUnquoteCall(node) {
+		defineScopedMacros(node, env, scopes)
+		return
+	}
+	if call, ok := node.(*ast.CallExpression); ok {
+		scopes[call] = nil
+	}
+	for _, child := range ast.Children(node) {
+		markQuotedCalls(child, env, scopes)
+	}
+}
+
+func isQuoteCall(node ast.Node) bool {
+	callExpression, ok := node.(*ast.CallExpression)
+	return ok && callExpression.Function.TokenLiteral() == "quote"
+}
+
+func hasMacroDefinition(statements []ast.Statement) bool {
+	for _, statement := range statements {
+		if isMacroDefinition(statement) {
+			return true
+		}
+	}
+	return false
+}
+
 func isMacroDefinition(node ast.Statement) bool {
 	letStatement, ok := node.(*ast.LetStatement)
 	if !ok {
@@ -42,36 +177,88 @@ func addMacro(stmt ast.Statement, env *object.Environment) {
 		Parameters: macroLiteral.Parameters,
 		Env:        env,
 		Body:       macroLiteral.Body,
+		Hygienic:   macroLiteral.Hygienic,
 	}
 
 	env.Set(letStatement.Name.Value, macro)
 }
+
+// ExpandMacros replaces the macro calls in program with the AST returned by
+// the macro. Macros defined inside a block are only visible in that block and
+// calls inside quote are left for macroexpand. A macro gets its arguments
+// unexpanded, the macro calls in its result are expanded afterwards.
 func ExpandMacros(program ast.Node, env *object.Environment) ast.Node {
+	scopes := map[*ast.CallExpression]*object.Environment{}
+	defineScopedMacros(program, env, scopes)
+
 	return ast.Modify(program, func(node ast.Node) ast.Node {
 		callExpression, ok := node.(*ast.CallExpression)
 		if !ok {
 			return node
 		}
 
-		macro, ok := isMacroCall(callExpression, env)
+		scope, ok := scopes[callExpression]
 		if !ok {
+			scope = env
+		} else if scope == nil {
 			return node
 		}
+		macro, ok := isMacroCall(callExpression, scope)
+		if !ok {
+			return node
+		}
+
+		return ExpandMacros(expandMacroCall(callExpression, macro), scope)
+	})
+}
+
+func expandMacroCall(call *ast.CallExpression, macro *object.Macro) ast.Node {
+	if len(call.Arguments) != len(macro.Parameters) {
+		return &ast.ErrorLiteral{
+			Line: call.TokenLine(),
+			Message: fmt.Sprintf("wrong number of arguments for macro %s. got=%d, want=%d",
+				call.Function.String(), len(call.Arguments), len(macro.Parameters)),
+		}
+	}
 
-		args := quoteArgs(callExpression)
-		evalEnv := extendMacroEnv(macro, args)
+	args := quoteArgs(call)
+	evalEnv := extendMacroEnv(macro, args)
 
-		evaluated := Eval(macro.Body, evalEnv)
+	evaluated := Eval(macro.Body, evalEnv)
 
-		switch quote := evaluated.(type) {
-		case *object.Quote:
-			return quote.Node
-		case *object.Error:
-			return &ast.ErrorLiteral{Line: quote.Line, Message: quote.Message}
-		default:
-			return &ast.ErrorLiteral{Line: macro.Body.TokenLine(), Message: "we only support returning AST-nodes from macros"}
+	switch quote := evaluated.(type) {
+	case *object.Quote:
+		expanded := quote.Node
+		if macro.Hygienic {
+			expanded = renameBindings(expanded, args)
 		}
-	})
+		moveToCallSite(expanded, args, call.TokenLine())
+		return expanded
+	case *object.Error:
+		return &ast.ErrorLiteral{Line: quote.Line, Message: quote.Message}
+	default:
+		return &ast.ErrorLiteral{Line: macro.Body.TokenLine(), Message: "we only support returning AST-nodes from macros"}
+	}
+}
+
+// evalMacroexpand evaluates the special form macroexpand(quoted). Quoted
+// code is not expanded by ExpandMacros, this expands it with the macros
+// visible in env.
+func evalMacroexpand(node *ast.CallExpression, env *object.Environment) object.Object {
+	if len(node.Arguments) != 1 {
+		return setLineError(node, newError("wrong number of arguments. got=%d, want=1",
+			len(node.Arguments)))
+	}
+	arg := Eval(node.Arguments[0], env)
+	if isError(arg) {
+		return setLineError(node, arg)
+	}
+	quote, ok := arg.(*object.Quote)
+	if !ok {
+		return setLineError(node, newError("argument to `macroexpand` must be QUOTE, got %s",
+			arg.Type()))
+	}
+	return &object.Quote{Node: ExpandMacros(ast.Copy(quote.Node), env)}
 }
 
 func isMacroCall(
@@ -118,3 +305,208 @@ func extendMacroEnv(
 
 	return extended
 }
+
+// gensymCounter numbers the identifiers created by gensym.
+var gensymCounter int
+
+// gensymSeparator separates the prefix of a gensym name from its number.
+// Names ending in it and a number are reserved for gensym.
+const gensymSeparator = "__"
+
+// gensym returns a fresh identifier name. The name is a valid identifier,
+// so expanded code can be printed and parsed again.
+func gensym(prefix string) string {
+	if i := strings.LastIndex(prefix, gensymSeparator); i >= 0 &&
+		isGensymNumber(prefix[i+len(gensymSeparator):]) {
+		prefix = prefix[:i]
+	}
+	gensymCounter++
+	return fmt.Sprintf("%s%s%d", prefix, gensymSeparator, gensymCounter)
+}
+
+func isGensymNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// renameBindings gives every name bound by a let or struct statement, an
+// array or match pattern or a function parameter inside the expanded node a
+// fresh gensym name, so that it can not capture or clobber identifiers in the
+// caller's code. Only the identifiers in the scope of a binding are renamed,
+// free identifiers and the macro arguments from the caller are left
+// untouched.
+func renameBindings(expanded ast.Node, args []*object.Quote) ast.Node {
+	r := &renamer{
+		scope:      &hygieneScope{names: map[string]string{}},
+		fromCaller: callerNodes(args),
+		fields:     map[*ast.Identifier]bool{},
+	}
+	r.declare(expanded)
+	ast.Walk(r, expanded)
+	return expanded
+}
+
+// hygieneScope maps the names bound in one scope of the expanded code to
+// their gensym names.
+type hygieneScope struct {
+	names map[string]string
+	outer *hygieneScope
+}
+
+func (s *hygieneScope) bind(ident *ast.Identifier) {
+	if _, ok := s.names[ident.Value]; !ok {
+		s.names[ident.Value] = gensym(ident.Value)
+	}
+}
+
+func (s *hygieneScope) lookup(name string) (string, bool) {
+	for ; s != nil; s = s.outer {
+		if renamed, ok := s.names[name]; ok {
+			return renamed, true
+		}
+	}
+	return "", false
+}
+
+// renamer is the ast.Visitor of renameBindings. Every node that gets its own
+// environment at runtime opens a new scope.
+type renamer struct {
+	scope      *hygieneScope
+	fromCaller map[ast.Node]bool
+	fields     map[*ast.Identifier]bool
+}
+
+func (r *renamer) Visit(node, parent ast.Node) ast.Visitor {
+	if r.fromCaller[node] {
+		return nil
+	}
+	// Field names are not bindings, p.x keeps its x whatever the macro
+	// binds.
+	switch node := node.(type) {
+	case *ast.StructStatement:
+		for _, field := range node.Fields {
+			r.fields[field] = true
+		}
+	case *ast.MemberExpression:
+		r.fields[node.Member] = true
+	case *ast.MemberAssignExpression:
+		r.fields[node.Member] = true
+	case *ast.Identifier:
+		if name, ok := r.scope.lookup(node.Value); ok && !r.fields[node] {
+			node.Value = name
+			node.Token.Literal = name
+		}
+	}
+	if parent == nil || !opensScope(node) {
+		return r
+	}
+	inner := &renamer{
+		scope:      &hygieneScope{names: map[string]string{}, outer: r.scope},
+		fromCaller: r.fromCaller,
+		fields:     r.fields,
+	}
+	inner.declare(node)
+	return inner
+}
+
+// opensScope reports whether node gets its own environment at runtime.
+func opensScope(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.FunctionLiteral, *ast.IfExpression, *ast.WhileStatement,
+		*ast.ForStatement, *ast.MatchArm:
+		return true
+	}
+	return false
+}
+
+// declare binds the names of the scope opened by node: the parameters of a
+// function, the identifiers of a match pattern and every let or struct
+// statement that is not inside a nested scope. A binding covers its whole
+// scope, like the environment it is stored in.
+func (r *renamer) declare(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.FunctionLiteral:
+		for _, param := range node.Parameters {
+			r.scope.bind(param)
+		}
+		if node.Rest != nil {
+			r.scope.bind(node.Rest)
+		}
+	case *ast.MatchArm:
+		ast.Inspect(node.Pattern, func(node, parent ast.Node) bool {
+			if r.fromCaller[node] {
+				return false
+			}
+			if ident, ok := node.(*ast.Identifier); ok && ident.Value != "_" {
+				r.scope.bind(ident)
+			}
+			return true
+		})
+	}
+	ast.Walk(visitFunc(func(child ast.Node) bool {
+		if r.fromCaller[child] || child != node && opensScope(child) {
+			return false
+		}
+		switch child := child.(type) {
+		case *ast.LetStatement:
+			r.scope.bind(child.Name)
+		case *ast.DestructuringLetStatement:
+			switch pattern := child.Pattern.(type) {
+			case *ast.ArrayPattern:
+				for _, element := range pattern.Elements {
+					r.scope.bind(element)
+				}
+				if pattern.Rest != nil {
+					r.scope.bind(pattern.Rest)
+				}
+			case *ast.HashPattern:
+				// The keys are strings, renaming a name keeps its key.
+				for _, name := range pattern.Names {
+					r.scope.bind(name)
+				}
+			}
+		case *ast.StructStatement:
+			r.scope.bind(child.Name)
+		}
+		return true
+	}), node)
+}
+
+// visitFunc is an ast.Visitor that skips the children of every node
+// for which the function returns false.
+type visitFunc func(node ast.Node) bool
+
+func (f visitFunc) Visit(node, parent ast.Node) ast.Visitor {
+	if !f(node) {
+		return nil
+	}
+	return f
+}
+
+// moveToCallSite gives the nodes generated by a macro the line of the macro
+// call, leaving the arguments written by the caller at their own lines.
+func moveToCallSite(expanded ast.Node, args []*object.Quote, line int) {
+	fromCaller := callerNodes(args)
+	ast.Walk(visitFunc(func(node ast.Node) bool {
+		if fromCaller[node] {
+			return false
+		}
+		ast.SetLine(node, line)
+		return true
+	}), expanded)
+}
+
+func callerNodes(args []*object.Quote) map[ast.Node]bool {
+	fromCaller := map[ast.Node]bool{}
+	for _, arg := range args {
+		fromCaller[arg.Node] = true
+	}
+	return fromCaller
+}
@@ -5,6 +5,8 @@ import (
 	"lang/lexer"
 	"lang/object"
 	"lang/parser"
+	"lang/token"
+	"strings"
 	"testing"
 )
 
@@ -120,3 +122,431 @@ func TestExpandMacros(t *testing.T) {
 		}
 	}
 }
+
+func TestMacroExpandedTwice(t *testing.T) {
+	input := `
+	let unless = macro(condition, consequence, alternative) {
+		quote(if (!(unquote(condition))) { unquote(consequence); } else { unquote(alternative); });
+	};
+	[unless(1 > 2, "a", "b"), unless(1 < 2, "c", "d")];
+	`
+	program := testParseProgram(input)
+	env := object.NewEnvironment()
+	DefineMacros(program, env)
+	expanded := ExpandMacros(program, env)
+
+	evaluated := Eval(expanded, object.NewEnvironment())
+	if evaluated.Inspect() != "[a, d]" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestMacroExpansionOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`
+			let double = macro(x) { quote(unquote(x) * 2); };
+			let show = macro(x) { quote(unquote(str(x))); };
+			show(double(1));
+			`,
+			"QUOTE(double(1))",
+		},
+		{
+			`
+			let double = macro(x) { quote(unquote(x) * 2); };
+			double(double(1));
+			`,
+			"4",
+		},
+		{
+			`
+			let double = macro(x) { quote(unquote(x) * 2); };
+			let quad = macro(x) { quote(double(double(unquote(x)))); };
+			quad(3);
+			`,
+			"12",
+		},
+		{
+			`
+			let double = macro(x) { quote(unquote(x) * 2); };
+			macroexpand(quote(double(double(1))));
+			`,
+			"QUOTE(((1 * 2) * 2))",
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded := ExpandMacros(program, env)
+		evaluated := Eval(expanded, env)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result. expected=%q, got=%q", tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHygienicMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{
+			`
+			let add = macro(a, b) { quote(fn() { let tmp = unquote(a); tmp + unquote(b); }()); };
+			let tmp = 10;
+			add(1, tmp);
+			`,
+			2,
+		},
+		{
+			`
+			let add = hygienic macro(a, b) { quote(fn() { let tmp = unquote(a); tmp + unquote(b); }()); };
+			let tmp = 10;
+			add(1, tmp);
+			`,
+			11,
+		},
+		{
+			`
+			let twice = hygienic macro(x) { quote(fn(n) { n + n; }(unquote(x))); };
+			let n = 4;
+			twice(n + 1);
+			`,
+			10,
+		},
+		{
+			`
+			let sum = hygienic macro(pair) { quote(fn() { let [a, b] = unquote(pair); a + b; }()); };
+			let a = 10;
+			sum([1, a]);
+			`,
+			11,
+		},
+		{
+			`
+			let addFirst = hygienic macro(x, y) { quote(match (unquote(x)) { [tmp] => tmp + unquote(y) }); };
+			let tmp = 10;
+			addFirst([1], tmp);
+			`,
+			11,
+		},
+		{
+			`
+			let sumX = hygienic macro(a, b) { quote(fn() { struct P { x } let x = P(unquote(a)); x.x + unquote(b).x; }()); };
+			struct P { x }
+			let x = P(10);
+			sumX(1, x);
+			`,
+			11,
+		},
+		{
+			`
+			let plusTmp = hygienic macro(x) { quote(fn(tmp) { tmp; }(unquote(x)) + tmp); };
+			let tmp = 10;
+			plusTmp(1);
+			`,
+			11,
+		},
+		{
+			`
+			let both = hygienic macro(x) { quote(fn() { let f = fn() { g(); }; let g = fn() { unquote(x); }; f(); }()); };
+			let g = 10;
+			both(g + 1);
+			`,
+			11,
+		},
+		{
+			`
+			let add = hygienic macro(a, b) { quote(fn() { let tmp = unquote(a); if (true) { let tmp = 1; } tmp + unquote(b); }()); };
+			let tmp = 10;
+			add(2, tmp);
+			`,
+			12,
+		},
+		{
+			`
+			let pick = hygienic macro(h, y) { quote(fn() { let {tmp} = unquote(h); tmp + unquote(y); }()); };
+			let tmp = 10;
+			pick({"tmp": 1}, tmp);
+			`,
+			11,
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded := ExpandMacros(program, env)
+
+		testIntegerObject(t, Eval(expanded, object.NewEnvironment()), tt.expected)
+	}
+}
+
+func TestGensym(t *testing.T) {
+	first, ok := testEval(`gensym("tmp");`).(*object.Quote)
+	if !ok {
+		t.Fatalf("gensym did not return Quote.")
+	}
+	second, ok := testEval(`gensym("tmp");`).(*object.Quote)
+	if !ok {
+		t.Fatalf("gensym did not return Quote.")
+	}
+	ident, ok := first.Node.(*ast.Identifier)
+	if !ok {
+		t.Fatalf("gensym did not quote an Identifier. got=%T", first.Node)
+	}
+	if !strings.HasPrefix(ident.Value, "tmp__") {
+		t.Errorf("wrong gensym name. got=%q", ident.Value)
+	}
+	// The name can be printed and parsed again as an identifier.
+	tok := lexer.New(ident.Value).NextToken()
+	if tok.Type != token.IDENT || tok.Literal != ident.Value {
+		t.Errorf("gensym name is not an identifier. got=%s %q", tok.Type, tok.Literal)
+	}
+	if renamed := gensym(ident.Value); !strings.HasPrefix(renamed, "tmp__") ||
+		strings.Count(renamed, "__") != 1 {
+		t.Errorf("gensym of a gensym name kept the old number. got=%q", renamed)
+	}
+	if first.Node.String() == second.Node.String() {
+		t.Errorf("gensym returned the same name twice: %s", first.Node.String())
+	}
+
+	errObj, ok := testEval(`gensym(1);`).(*object.Error)
+	if !ok {
+		t.Fatalf("expected error for gensym(1)")
+	}
+	if errObj.Message != "argument to `gensym` must be STRING, got INTEGER" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestScopedMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			`
+			let f = fn(x) {
+				let twice = macro(e) { quote(unquote(e) * 2); };
+				twice(x) + 1;
+			};
+			f(5);
+			`,
+			11,
+		},
+		{
+			`
+			let twice = macro(e) { quote(unquote(e) * 2); };
+			let f = fn(x) {
+				let twice = macro(e) { quote(unquote(e) * 3); };
+				if (true) { twice(x); }
+			};
+			[f(2), twice(2)];
+			`,
+			"[6, 4]",
+		},
+		{
+			`
+			let f = fn() { let m = macro() { quote(1); }; m(); };
+			m();
+			`,
+			"identifier not found: m",
+		},
+		{
+			`
+			let a = later(1);
+			let later = macro(e) { quote(unquote(e)); };
+			`,
+			"macro used before definition: later",
+		},
+		{
+			`
+			let f = fn() {
+				if (true) { inner(); }
+				let inner = macro() { quote(1); };
+			};
+			f();
+			`,
+			"macro used before definition: inner",
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded := ExpandMacros(program, env)
+		evaluated := Eval(expanded, object.NewEnvironment())
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q",
+						expected, errObj.Message)
+				}
+				continue
+			}
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result. expected=%s, got=%s", expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestDefineMacroLibrary(t *testing.T) {
+	library := testParseProgram(`
+		let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a); } else { unquote(b); }); };
+	`)
+	env := object.NewEnvironment()
+	if err := DefineMacroLibrary(library, env); err != nil {
+		t.Fatalf("DefineMacroLibrary failed: %s", err)
+	}
+	program := testParseProgram(`unless(1 > 2, "yes", "no");`)
+	DefineMacros(program, env)
+	expanded := ExpandMacros(program, env)
+	evaluated := Eval(expanded, object.NewEnvironment())
+	if evaluated.Inspect() != "yes" {
+		t.Errorf("wrong result. got=%s", evaluated.Inspect())
+	}
+
+	err := DefineMacroLibrary(testParseProgram("let x = 1;"), object.NewEnvironment())
+	expected := "line 1: macro libraries may only contain macro definitions"
+	if err == nil || err.Error() != expected {
+		t.Errorf("wrong error. want=%q, got=%v", expected, err)
+	}
+}
+
+func TestMacroexpand(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`
+			let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a); } else { unquote(b); }); };
+			quote(unless(true, 1, 2));
+			`,
+			`QUOTE(unless(true, 1, 2))`,
+		},
+		{
+			`
+			let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a); } else { unquote(b); }); };
+			macroexpand(quote(unless(true, 1, 2)));
+			`,
+			`QUOTE(if(!true) 1else 2)`,
+		},
+		{
+			`
+			let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a); } else { unquote(b); }); };
+			let q = quote(unless(true, 1, 2));
+			macroexpand(q);
+			`,
+			`QUOTE(if(!true) 1else 2)`,
+		},
+		{
+			`
+			let f = fn() {
+				let double = macro(x) { quote(unquote(x) * 2); };
+				macroexpand(quote(double(3)));
+			};
+			f();
+			`,
+			`QUOTE((3 * 2))`,
+		},
+		{
+			`
+			let f = fn() {
+				let m = macro(x) { quote(unquote(x) + 1); };
+				let q = quote(m(2));
+				macroexpand(q);
+			};
+			f();
+			`,
+			`QUOTE((2 + 1))`,
+		},
+		{
+			`
+			let m = macro(x) { quote(unquote(x) * 2); };
+			let f = fn(q) {
+				let m = macro(x) { quote(unquote(x) + 1); };
+				macroexpand(q);
+			};
+			[f(quote(m(2))), macroexpand(quote(m(2)))];
+			`,
+			`[QUOTE((2 + 1)), QUOTE((2 * 2))]`,
+		},
+		{
+			`macroexpand(quote(1 + 2));`,
+			`QUOTE((1 + 2))`,
+		},
+		{
+			`macroexpand(1);`,
+			"argument to `macroexpand` must be QUOTE, got INTEGER",
+		},
+		{
+			`
+			let unless = macro(c, a, b) { quote(1); };
+			unless(true);
+			`,
+			"wrong number of arguments for macro unless. got=1, want=3",
+		},
+	}
+
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		// macroexpand sees the top-level macros when the program runs in
+		// the environment they are defined in, like in the REPL.
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded := ExpandMacros(program, env)
+		evaluated := Eval(expanded, env)
+
+		if errObj, ok := evaluated.(*object.Error); ok {
+			if errObj.Message != tt.expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					tt.expected, errObj.Message)
+			}
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result. expected=%q, got=%q", tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestExpandedCodeLineNumbers(t *testing.T) {
+	input := `
+let broken = macro(x) {
+	quote(unquote(x) + missing);
+};
+
+let a = 1;
+broken(a);
+`
+	program := testParseProgram(input)
+	env := object.NewEnvironment()
+	DefineMacros(program, env)
+	expanded := ExpandMacros(program, env)
+	evaluated := Eval(expanded, object.NewEnvironment())
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: missing" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+	if errObj.Line != 7 {
+		t.Errorf("error does not point to the macro call. want line 7, got=%d", errObj.Line)
+	}
+}
@@ -0,0 +1,121 @@
+package evaluator
+
+import (
+	"lang/ast"
+	"lang/object"
+)
+
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(me.Subject, env)
+	if isError(subject) {
+		return setLineError(me, subject)
+	}
+	for _, arm := range me.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return setLineError(arm, err)
+		}
+		if !matched {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return setLineError(arm, guard)
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return Eval(arm.Body, armEnv)
+	}
+	return NULL
+}
+
+// matchPattern reports whether value has the shape of pattern and binds the
+// identifiers of the pattern in env. The identifier _ matches any value
+// without binding it.
+func matchPattern(pattern ast.Expression, value object.Object, env *object.Environment) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			env.Set(pattern.Value, value)
+		}
+		return true, nil
+	case *ast.ArrayLiteral:
+		array, ok := value.(*object.Array)
+		if !ok {
+			return false, nil
+		}
+		elements := pattern.Elements
+		var rest *ast.RestPattern
+		if n := len(elements); n > 0 {
+			rest, _ = elements[n-1].(*ast.RestPattern)
+		}
+		if rest != nil {
+			elements = elements[:len(elements)-1]
+			if len(array.Elements) < len(elements) {
+				return false, nil
+			}
+		} else if len(array.Elements) != len(elements) {
+			return false, nil
+		}
+		for i, element := range elements {
+			matched, err := matchPattern(element, array.Elements[i], env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		if rest != nil {
+			remaining := make([]object.Object, len(array.Elements)-len(elements))
+			copy(remaining, array.Elements[len(elements):])
+			env.Set(rest.Name.Value, &object.Array{Elements: remaining})
+		}
+		return true, nil
+	case *ast.HashLiteral:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+		for keyNode, valuePattern := range pattern.Pairs {
+			key := Eval(keyNode, env)
+			if isError(key) {
+				return false, key.(*object.Error)
+			}
+			hashKey, ok := key.(object.Hashable)
+			if !ok {
+				return false, newError("unusable as hash key: %s", key.Type())
+			}
+			pair, ok := hash.Pairs[hashKey.HashKey()]
+			if !ok {
+				return false, nil
+			}
+			matched, err := matchPattern(valuePattern, pair.Value, env)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	default:
+		expected := Eval(pattern, env)
+		if isError(expected) {
+			return false, expected.(*object.Error)
+		}
+		return literalMatches(expected, value), nil
+	}
+}
+
+// literalMatches compares a literal pattern with a value like ==, so 2
+// matches 2.0 and nan matches nothing. Unlike ==, values of different types
+// simply don't match.
+func literalMatches(expected, value object.Object) bool {
+	if isNumber(expected) && isNumber(value) {
+		return isTruthy(evalInfixExpression("==", expected, value))
+	}
+	if expected, ok := expected.(*object.String); ok {
+		value, ok := value.(*object.String)
+		return ok && expected.Value == value.Value
+	}
+	return expected == value
+}
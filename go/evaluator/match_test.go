@@ -0,0 +1,68 @@
+package evaluator
+
+import (
+	"lang/object"
+	"testing"
+)
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`match (1) { 0 => "zero", 1 => "one", _ => "many" }`, "one"},
+		{`match (7) { 0 => "zero", 1 => "one", _ => "many" }`, "many"},
+		{`match (-1) { -1 => "minus one", _ => "other" }`, "minus one"},
+		{`match (2.0) { 2 => "two", _ => "other" }`, "two"},
+		{`match (2) { 2.0 => "two", _ => "other" }`, "two"},
+		{`match (2.5) { 2 => "two", _ => "other" }`, "other"},
+		{`match (pow(2, 70)) { 1180591620717411303424.0 => "big", _ => "other" }`, "big"},
+		{`match (nan) { nan => "nan", _ => "other" }`, "other"},
+		{`match ([nan]) { [nan] => "nan", [x] => "other" }`, "other"},
+		{`match (0.0 / 0) { n if n == n => "number", _ => "nan" }`, "nan"},
+		{`match (1) { true => "bool", "1" => "string", _ => "other" }`, "other"},
+		{`match ("a") { "a" => 1, _ => 2 }`, 1},
+		{`match ("1") { 1 => "int", "1" => "string" }`, "string"},
+		{`match (true) { false => 0, true => 1 }`, 1},
+		{`match (5) { n => n * 2 }`, 10},
+		{`match ([1, 2]) { [a] => a, [a, b] => a + b }`, 3},
+		{`match ([1, 2, 3]) { [first, ...rest] => first + len(rest) }`, 3},
+		{`match ([]) { [first, ...rest] => first, [] => 0 }`, 0},
+		{`match ([1, [2, 3]]) { [1, [_, x]] => x }`, 3},
+		{`match ({"name": "Monkey", "age": 3}) { {"name": n, "age": 3} => n }`, "Monkey"},
+		{`match ({"name": "Monkey"}) { {"age": a} => a, {"name": n} => n }`, "Monkey"},
+		{`match ({1: true}) { {1: true} => "yes", _ => "no" }`, "yes"},
+		{`match (4) { n if n > 5 => "big", n if n > 2 => "medium", _ => "small" }`, "medium"},
+		{`match ([3, 4]) { [a, b] if a > b => a, [a, b] => b }`, 4},
+		{`let n = 1; match (2) { n => n }; n;`, 1},
+		{`match (1) { 2 => 2 }`, nil},
+		{`match ("x") { [a] => a, {"a": a} => a }`, nil},
+		{`match (1 + true) { _ => 1 }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`match (1) { n if n + true => 1 }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`match (1) { n => n + true }`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			if evaluated != NULL {
+				t.Errorf("object is not NULL for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("wrong value for %q. expected=%q, got=%q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, obj.Message)
+				}
+			default:
+				t.Errorf("unexpected object for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
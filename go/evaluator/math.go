@@ -0,0 +1,163 @@
+package evaluator
+
+import (
+	"lang/object"
+	"math"
+	"math/big"
+	"math/rand"
+	"time"
+)
+
+var mathConstants = map[string]object.Object{
+	"PI": &object.Float{Value: math.Pi},
+	"E":  &object.Float{Value: math.E},
+}
+
+// rng backs `random` and is reseeded by `seed`.
+var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+func isNumber(obj object.Object) bool {
+	switch obj.Type() {
+	case object.INTEGER_OBJ, object.BIGINT_OBJ, object.FLOAT_OBJ:
+		return true
+	}
+	return false
+}
+
+// newFloatBuiltin wraps a float64 function as a builtin that accepts any
+// number and always returns FLOAT.
+func newFloatBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			if !isNumber(args[0]) {
+				return newError("argument to `%s` must be INTEGER or FLOAT, got %s",
+					name, args[0].Type())
+			}
+			return &object.Float{Value: fn(getFloatNumber(args[0]))}
+		},
+	}
+}
+
+// newRoundingBuiltin wraps a float64 rounding function as a builtin that
+// returns INTEGER, leaving integer arguments unchanged.
+func newRoundingBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return arg
+			case *object.Float:
+				return floatToInteger(fn(arg.Value))
+			default:
+				return newError("argument to `%s` must be INTEGER or FLOAT, got %s",
+					name, args[0].Type())
+			}
+		},
+	}
+}
+
+// floatToInteger truncates value towards zero.
+func floatToInteger(value float64) object.Object {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return newError("can not convert %s to INTEGER", (&object.Float{Value: value}).Inspect())
+	}
+	if value >= math.MinInt64 && value < math.MaxInt64 {
+		return &object.Integer{Value: int64(value)}
+	}
+	integer, _ := big.NewFloat(value).Int(nil)
+	return newBigInteger(integer)
+}
+
+func mathAbs(arg object.Object) object.Object {
+	switch arg := arg.(type) {
+	case *object.Integer:
+		if arg.Value < 0 {
+			return evalMinusPrefixOperatorExpression(arg)
+		}
+		return arg
+	case *object.BigInt:
+		return newBigInteger(new(big.Int).Abs(arg.Value))
+	case *object.Float:
+		return &object.Float{Value: math.Abs(arg.Value)}
+	default:
+		return newError("argument to `abs` must be INTEGER or FLOAT, got %s",
+			arg.Type())
+	}
+}
+
+// maxPowBits limits the size of integer `pow` results, so a huge exponent
+// is an error instead of a hang.
+const maxPowBits = 1 << 20
+
+// mathPow keeps integer results for integer arguments with a non-negative
+// exponent and falls back to FLOAT otherwise.
+func mathPow(base, exponent object.Object) object.Object {
+	if !isNumber(base) || !isNumber(exponent) {
+		return newError("arguments to `pow` must be INTEGER or FLOAT, got %s and %s",
+			base.Type(), exponent.Type())
+	}
+	if base.Type() != object.FLOAT_OBJ && exponent.Type() == object.INTEGER_OBJ &&
+		exponent.(*object.Integer).Value >= 0 {
+		b, e := getBigIntNumber(base), exponent.(*object.Integer).Value
+		// |base| <= 1 never grows, otherwise the result has at least
+		// (bitlen(base) - 1) * exponent bits
+		if bits := int64(b.BitLen() - 1); bits > 0 && e > maxPowBits/bits {
+			return newError("`pow` result is too large: more than %d bits", maxPowBits)
+		}
+		return newBigInteger(new(big.Int).Exp(b, big.NewInt(e), nil))
+	}
+	return &object.Float{Value: math.Pow(getFloatNumber(base), getFloatNumber(exponent))}
+}
+
+// mathExtreme returns the argument (or array element) for which operator
+// holds against all others, keeping its original type.
+func mathExtreme(name, operator string, args []object.Object) object.Object {
+	if len(args) == 1 {
+		if arr, ok := args[0].(*object.Array); ok {
+			args = arr.Elements
+		}
+	}
+	if len(args) == 0 {
+		return newError("`%s` needs at least one number", name)
+	}
+	result := args[0]
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return newError("arguments to `%s` must be INTEGER or FLOAT, got %s",
+				name, arg.Type())
+		}
+		if evalInfixExpression(operator, arg, result) == TRUE {
+			result = arg
+		}
+	}
+	return result
+}
+
+func mathRandom(args []object.Object) object.Object {
+	switch len(args) {
+	case 0:
+		return &object.Float{Value: rng.Float64()}
+	case 1:
+		limit, ok := args[0].(*object.Integer)
+		if !ok {
+			return newError("argument to `random` must be INTEGER, got %s",
+				args[0].Type())
+		}
+		if limit.Value <= 0 {
+			return newError("argument to `random` must be positive, got %d",
+				limit.Value)
+		}
+		return &object.Integer{Value: rng.Int63n(limit.Value)}
+	default:
+		return newError("wrong number of arguments. got=%d, want=0 or 1",
+			len(args))
+	}
+}
@@ -0,0 +1,105 @@
+package evaluator
+
+import (
+	"lang/object"
+	"math"
+	"testing"
+)
+
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`abs(-5);`, 5},
+		{`abs(-2.5);`, 2.5},
+		{`floor(2.7);`, 2},
+		{`floor(-2.5);`, -3},
+		{`ceil(2.1);`, 3},
+		{`round(2.5);`, 3},
+		{`round(7);`, 7},
+		{`sqrt(16);`, 4.0},
+		{`pow(2, 10);`, 1024},
+		{`pow(2, -1);`, 0.5},
+		{`pow(2.0, 3);`, 8.0},
+		{`min(3, 1.5, 2);`, 1.5},
+		{`max(3, 1.5, 2);`, 3},
+		{`max([4, 9, 2]);`, 9},
+		{`sin(0);`, 0.0},
+		{`cos(0);`, 1.0},
+		{`log(E);`, 1.0},
+		{`exp(0);`, 1.0},
+		{`PI;`, math.Pi},
+		{`int(3.99);`, 3},
+		{`int(-3.99);`, -3},
+		{`float(3);`, 3.0},
+		{`abs("x");`, "argument to `abs` must be INTEGER or FLOAT, got STRING"},
+		{`sqrt(true);`, "argument to `sqrt` must be INTEGER or FLOAT, got BOOLEAN"},
+		{`min();`, "`min` needs at least one number"},
+		{`max(1, "2");`, "arguments to `max` must be INTEGER or FLOAT, got STRING"},
+		{`random(0);`, "argument to `random` must be positive, got 0"},
+		{`int(0.0 / 0.0);`, "can not convert nan to INTEGER"},
+		{`pow(2, 100000000000);`, "`pow` result is too large: more than 1048576 bits"},
+		{`pow(pow(10, 30), 100000);`, "`pow` result is too large: more than 1048576 bits"},
+		{`pow(1, 100000000000);`, 1},
+		{`pow(-1, 100000000001);`, -1},
+		{`pow(0, 100000000000);`, 0},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)",
+					evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestBigIntegerMath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`pow(2, 100);`, "1267650600228229401496703205376"},
+		{`abs(-pow(10, 20));`, "100000000000000000000"},
+		{`int(100000000000000000000.0);`, "100000000000000000000"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		result, ok := evaluated.(*object.BigInt)
+		if !ok {
+			t.Errorf("object is not BigInt. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if result.Value.String() != tt.expected {
+			t.Errorf("object has wrong value. got=%s, want=%s",
+				result.Value, tt.expected)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	first := testEval(`seed(42); [random(), random(100)];`).Inspect()
+	second := testEval(`seed(42); [random(), random(100)];`).Inspect()
+	if first != second {
+		t.Errorf("seeded random sequences differ. got=%s and %s", first, second)
+	}
+	for i := 0; i < 20; i++ {
+		value, ok := testEval(`random(10);`).(*object.Integer)
+		if !ok || value.Value < 0 || value.Value >= 10 {
+			t.Fatalf("random(10) out of range. got=%+v", value)
+		}
+	}
+}
@@ -8,31 +8,57 @@ import (
 )
 
 func quote(node ast.Node, env *object.Environment) object.Object {
-	res := evalUnquoteCalls(node, env)
+	res, err := evalUnquoteCalls(ast.Copy(node), env)
+	if err != nil {
+		return err
+	}
 	if res == nil {
 		return &object.Error{Message: "Failed to evaluate unquote inside of a macro", Line: node.TokenLine()}
 	}
 	return &object.Quote{Node: res}
 }
 
-func evalUnquoteCalls(quoted ast.Node, env *object.Environment) ast.Node {
-	return ast.Modify(quoted, func(node ast.Node) ast.Node {
-		if !isUnquoteCall(node) {
-			return node
-		}
-
-		call, ok := node.(*ast.CallExpression)
-		if !ok {
-			return node
+func evalUnquoteCalls(quoted ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var err *object.Error
+	fail := func(line int, format string, a ...interface{}) {
+		if err == nil {
+			err = &object.Error{Message: fmt.Sprintf(format, a...), Line: line}
 		}
+	}
 
-		if len(call.Arguments) != 1 {
-			return node
+	res := ast.Modify(quoted, func(node ast.Node) ast.Node {
+		switch node := node.(type) {
+		case *ast.CallExpression:
+			if isUnquoteCall(node) {
+				if len(node.Arguments) != 1 {
+					return node
+				}
+				unquoted := Eval(node.Arguments[0], env)
+				if errObj, ok := unquoted.(*object.Error); ok {
+					fail(node.TokenLine(), "%s", errObj.Message)
+					return node
+				}
+				return convertObjectToASTNode(unquoted)
+			}
+			node.Arguments = spliceExpressions(node.Arguments, env, fail)
+		case *ast.ArrayLiteral:
+			node.Elements = spliceExpressions(node.Elements, env, fail)
+		case *ast.BlockStatement:
+			node.Statements = spliceStatements(node.Statements, env, fail)
 		}
-
-		unquoted := Eval(call.Arguments[0], env)
-		return convertObjectToASTNode(unquoted)
+		return node
 	})
+
+	if err == nil && res != nil {
+		ast.Inspect(res, func(node, parent ast.Node) bool {
+			if isUnquoteSpliceCall(node) {
+				fail(node.TokenLine(), "unquote_splice is only allowed in call arguments, array literals and blocks")
+				return false
+			}
+			return true
+		})
+	}
+	return res, err
 }
 
 func isUnquoteCall(node ast.Node) bool {
@@ -44,6 +70,118 @@ func isUnquoteCall(node ast.Node) bool {
 	return callExpression.Function.TokenLiteral() == "unquote"
 }
 
+func isUnquoteSpliceCall(node ast.Node) bool {
+	callExpression, ok := node.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+
+	return callExpression.Function.TokenLiteral() == "unquote_splice"
+}
+
+// spliceExpressions replaces every unquote_splice call among expressions with
+// the elements of its evaluated argument.
+func spliceExpressions(
+	expressions []ast.Expression,
+	env *object.Environment,
+	fail func(line int, format string, a ...interface{}),
+) []ast.Expression {
+	spliced := []ast.Expression{}
+	for _, exp := range expressions {
+		if !isUnquoteSpliceCall(exp) {
+			spliced = append(spliced, exp)
+			continue
+		}
+		nodes, ok := evalUnquoteSplice(exp.(*ast.CallExpression), env, fail)
+		if !ok {
+			return expressions
+		}
+		for _, node := range nodes {
+			element, isExpression := node.(ast.Expression)
+			if !isExpression {
+				fail(exp.TokenLine(), "can not splice %s into an expression list", node.String())
+				return expressions
+			}
+			spliced = append(spliced, element)
+		}
+	}
+	return spliced
+}
+
+// spliceStatements replaces every statement consisting of an unquote_splice
+// call with one statement per element of its evaluated argument.
+func spliceStatements(
+	statements []ast.Statement,
+	env *object.Environment,
+	fail func(line int, format string, a ...interface{}),
+) []ast.Statement {
+	spliced := []ast.Statement{}
+	for _, stmt := range statements {
+		expStmt, ok := stmt.(*ast.ExpressionStatement)
+		if !ok || !isUnquoteSpliceCall(expStmt.Expression) {
+			spliced = append(spliced, stmt)
+			continue
+		}
+		nodes, ok := evalUnquoteSplice(expStmt.Expression.(*ast.CallExpression), env, fail)
+		if !ok {
+			return statements
+		}
+		for _, node := range nodes {
+			switch node := node.(type) {
+			case ast.Statement:
+				spliced = append(spliced, node)
+			case ast.Expression:
+				spliced = append(spliced, &ast.ExpressionStatement{Token: expStmt.Token, Expression: node})
+			}
+		}
+	}
+	return spliced
+}
+
+func evalUnquoteSplice(
+	call *ast.CallExpression,
+	env *object.Environment,
+	fail func(line int, format string, a ...interface{}),
+) ([]ast.Node, bool) {
+	if len(call.Arguments) != 1 {
+		fail(call.TokenLine(), "wrong number of arguments. got=%d, want=1", len(call.Arguments))
+		return nil, false
+	}
+	unquoted := Eval(call.Arguments[0], env)
+	var elements []object.Object
+	switch obj := unquoted.(type) {
+	case *object.Error:
+		fail(call.TokenLine(), "%s", obj.Message)
+		return nil, false
+	case *object.Array:
+		elements = obj.Elements
+	case *object.Quote:
+		if array, ok := obj.Node.(*ast.ArrayLiteral); ok {
+			nodes := make([]ast.Node, len(array.Elements))
+			for i, el := range array.Elements {
+				nodes[i] = el
+			}
+			return nodes, true
+		}
+		fail(call.TokenLine(), "argument to `unquote_splice` must be ARRAY, got QUOTE(%s)", obj.Node.String())
+		return nil, false
+	default:
+		fail(call.TokenLine(), "argument to `unquote_splice` must be ARRAY, got %s", unquoted.Type())
+		return nil, false
+	}
+
+	nodes := make([]ast.Node, len(elements))
+	for i, el := range elements {
+		node := convertObjectToASTNode(el)
+		if node == nil {
+			fail(call.TokenLine(), "can not convert %s to an AST node", el.Type())
+			return nil, false
+		}
+		nodes[i] = node
+	}
+	return nodes, true
+}
+
 func convertObjectToASTNode(obj object.Object) ast.Node {
 	switch obj := obj.(type) {
 	case *object.Integer:
@@ -52,6 +190,12 @@ func convertObjectToASTNode(obj object.Object) ast.Node {
 			Literal: fmt.Sprintf("%d", obj.Value),
 		}
 		return &ast.IntegerLiteral{Token: t, Value: obj.Value}
+	case *object.BigInt:
+		t := token.Token{
+			Type:    token.INT,
+			Literal: obj.Value.String(),
+		}
+		return &ast.BigIntegerLiteral{Token: t, Value: obj.Value}
 	case *object.Float:
 		t := token.Token{
 			Type:    token.FLOAT,
@@ -66,6 +210,32 @@ func convertObjectToASTNode(obj object.Object) ast.Node {
 			t = token.Token{Type: token.FALSE, Literal: "false"}
 		}
 		return &ast.Boolean{Token: t, Value: obj.Value}
+	case *object.String:
+		t := token.Token{Type: token.STRING, Literal: obj.Value}
+		return &ast.StringLiteral{Token: t, Value: obj.Value}
+	case *object.Array:
+		elements := make([]ast.Expression, len(obj.Elements))
+		for i, el := range obj.Elements {
+			element, ok := convertObjectToASTNode(el).(ast.Expression)
+			if !ok {
+				return nil
+			}
+			elements[i] = element
+		}
+		t := token.Token{Type: token.LBRACKET, Literal: "["}
+		return &ast.ArrayLiteral{Token: t, Elements: elements}
+	case *object.Hash:
+		pairs := make(map[ast.Expression]ast.Expression, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			key, keyOk := convertObjectToASTNode(pair.Key).(ast.Expression)
+			value, valueOk := convertObjectToASTNode(pair.Value).(ast.Expression)
+			if !keyOk || !valueOk {
+				return nil
+			}
+			pairs[key] = value
+		}
+		t := token.Token{Type: token.LBRACE, Literal: "{"}
+		return &ast.HashLiteral{Token: t, Pairs: pairs}
 	case *object.Quote:
 		return obj.Node
 	default:
@@ -95,6 +95,18 @@ func TestQuoteUnquote(t *testing.T) {
 			`quote(unquote(4 + 4) + 8);`,
 			`(8 + 8)`,
 		},
+		{
+			`quote(unquote("a" + "b"));`,
+			`"ab"`,
+		},
+		{
+			`quote(unquote([1, [2.5, true]]));`,
+			`[1, [2.500000, true]]`,
+		},
+		{
+			`quote(unquote({"a": 1}));`,
+			`{"a":1}`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -115,3 +127,86 @@ func TestQuoteUnquote(t *testing.T) {
 		}
 	}
 }
+
+func TestQuoteUnquoteSplice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let xs = [1, 2]; quote(f(0, unquote_splice(xs), 3));`,
+			`f(0, 1, 2, 3)`,
+		},
+		{
+			`quote([unquote_splice([]), 9]);`,
+			`[9]`,
+		},
+		{
+			`quote([unquote_splice([quote(a + b), "s"])]);`,
+			`[(a + b), "s"]`,
+		},
+		{
+			`let args = quote([x, y]); quote(f(unquote_splice(args)));`,
+			`f(x, y)`,
+		},
+		{
+			`quote(fn() { let a = 1; unquote_splice([quote(puts(a)), 2]); a; });`,
+			`fn() let a = 1;puts(a)2a`,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)",
+				evaluated, evaluated)
+		}
+
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q",
+				quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquoteSpliceErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`quote(f(unquote_splice(5)));`,
+			"argument to `unquote_splice` must be ARRAY, got INTEGER",
+		},
+		{
+			`quote(f(unquote_splice(quote(x))));`,
+			"argument to `unquote_splice` must be ARRAY, got QUOTE(x)",
+		},
+		{
+			`quote(f(unquote_splice([fn(x) { x; }])));`,
+			"can not convert FUNCTION to an AST node",
+		},
+		{
+			`quote(unquote_splice([1]) + 1);`,
+			"unquote_splice is only allowed in call arguments, array literals and blocks",
+		},
+		{
+			`quote(unquote(missing));`,
+			"identifier not found: missing",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expected, errObj.Message)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package evaluator
+
+import (
+	"fmt"
+	"lang/ast"
+	"lang/object"
+)
+
+func evalStructStatement(node *ast.StructStatement, env *object.Environment) object.Object {
+	if _, ok := env.GetCurrScope(node.Name.Value); ok {
+		return &object.Error{Line: node.TokenLine(), Message: fmt.Sprintf("Identifier %s already exists", node.Name.Value)}
+	}
+	structType := &object.StructType{
+		Name:    node.Name.Value,
+		Fields:  make([]string, len(node.Fields)),
+		Methods: make(map[string]*object.Function, len(node.Methods)),
+	}
+	for i, field := range node.Fields {
+		structType.Fields[i] = field.Value
+	}
+	for _, lit := range node.Methods {
+		method, ok := Eval(lit, env).(*object.Function)
+		if !ok {
+			return &object.Error{Line: lit.TokenLine(), Message: fmt.Sprintf("invalid method %s for struct %s", lit.Name, node.Name.Value)}
+		}
+		method.Name = node.Name.Value + "." + lit.Name
+		structType.Methods[lit.Name] = method
+	}
+	env.Set(node.Name.Value, structType)
+	return nil
+}
+
+func newStruct(structType *object.StructType, args []object.Object) object.Object {
+	if len(args) != len(structType.Fields) {
+		return newError("wrong number of arguments. got=%d, want=%d",
+			len(args), len(structType.Fields))
+	}
+	fields := make(map[string]object.Object, len(args))
+	for i, name := range structType.Fields {
+		fields[name] = args[i]
+	}
+	return &object.Struct{StructType: structType, Fields: fields}
+}
+
+func evalMemberExpression(node *ast.MemberExpression, env *object.Environment) object.Object {
+	obj := Eval(node.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	name := node.Member.Value
+	switch obj := obj.(type) {
+	case *object.Struct:
+		if value, ok := obj.Fields[name]; ok {
+			return value
+		}
+		if method, ok := obj.StructType.Methods[name]; ok {
+			return bindMethod(name, method, obj)
+		}
+		return newError("unknown field or method %s for struct %s", name, obj.StructType.Name)
+	case *object.Array, *object.String, *object.Hash:
+		if method, ok := builtinMethod(obj, name); ok {
+			return method
+		}
+		return newError("unknown method %s for %s", name, obj.Type())
+	default:
+		return newError("field access not supported: %s", obj.Type())
+	}
+}
+
+// bindMethod returns the method with self bound to the struct it was
+// accessed on, so p.sum can be called later like any other function. The
+// method's own name is rebound to the bound copy, so a recursive call keeps
+// self.
+func bindMethod(name string, method *object.Function, self *object.Struct) *object.Function {
+	bound := *method
+	bound.Env = object.NewEnclosedEnvironment(method.Env)
+	bound.Env.Set("self", self)
+	bound.Env.Set(name, &bound)
+	return &bound
+}
+
+// builtinMethods lists the builtins that arrays, strings and hashes expose as
+// methods. value.name(args) calls the builtin with value as first argument.
+var builtinMethods = map[object.ObjectType]map[string]bool{
+	object.ARRAY_OBJ: {
+		"len": true, "first": true, "last": true, "rest": true, "push": true,
+		"freeze": true, "str": true, "type": true, "json_stringify": true,
+	},
+	object.STRING_OBJ: {
+		"len": true, "int": true, "float": true, "bool": true, "str": true,
+		"type": true, "json_parse": true,
+	},
+	object.HASH_OBJ: {
+		"len": true, "add": true, "freeze": true, "str": true, "type": true,
+		"json_stringify": true,
+	},
+}
+
+func builtinMethod(receiver object.Object, name string) (*object.Builtin, bool) {
+	if !builtinMethods[receiver.Type()][name] {
+		return nil, false
+	}
+	builtin := builtins[name]
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		return builtin.Fn(append([]object.Object{receiver}, args...)...)
+	}}, true
+}
+
+func evalMemberAssignExpression(node *ast.MemberAssignExpression, env *object.Environment) object.Object {
+	obj := Eval(node.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	s, ok := obj.(*object.Struct)
+	if !ok {
+		return newError("field assignment not supported: %s", obj.Type())
+	}
+	if !s.StructType.HasField(node.Member.Value) {
+		return newError("unknown field %s for struct %s", node.Member.Value, s.StructType.Name)
+	}
+	if s.Frozen {
+		return newError("can not assign to field of frozen %s", s.StructType.Name)
+	}
+	s.Fields[node.Member.Value] = val
+	return val
+}
@@ -0,0 +1,131 @@
+package evaluator
+
+import (
+	"lang/object"
+	"testing"
+)
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"struct Point { x, y } let p = Point(1, 2); p.x * 10 + p.y;", 12},
+		{"struct Point { x, y } let p = Point(1, 2); p.x = 5; p.x;", 5},
+		{"struct Point { x, y } let p = Point(1, 2); p.y = p.x + 1;", 2},
+		{"struct Box { v } let b = Box(Box(7)); b.v.v;", 7},
+		{"struct Box { v } let b = Box([1, 2]); b.v[1];", 2},
+		{"struct Box { v } let f = fn(b) { b.v = b.v + 1; }; let b = Box(1); f(b); b.v;", 2},
+		{"struct Empty { } type(Empty());", "Empty"},
+		{"struct Point { x, y } let p = Point(1, 2); type(p);", "Point"},
+		{"struct Point { x, y } type(Point);", "STRUCT_TYPE"},
+		{"struct Point { x, y } Point(1);", "wrong number of arguments. got=1, want=2"},
+		{"struct Point { x, y } Point(1, 2).z;", "unknown field or method z for struct Point"},
+		{"struct Point { x, y } let p = Point(1, 2); p.z = 3;", "unknown field z for struct Point"},
+		{"struct Point { x, y } let p = freeze(Point(1, 2)); p.x = 3;", "can not assign to field of frozen Point"},
+		{"let a = 1; a.x;", "field access not supported: INTEGER"},
+		{`let h = {"x": 1}; h.x = 2;`, "field assignment not supported: HASH"},
+		{"let Point = 1; struct Point { x }", "Identifier Point already exists"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("wrong value for %q. expected=%q, got=%q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, obj.Message)
+				}
+			default:
+				t.Errorf("unexpected object for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestStructInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`struct Point { x, y } Point(1, "a");`, "Point{x: 1, y: a}"},
+		{"struct Empty { } Empty();", "Empty{}"},
+		{"struct Point { x, y } Point;", "<struct Point>"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect. expected=%q, got=%q", tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"struct Point { x, y, fn sum() { self.x + self.y; } } Point(1, 2).sum();", 3},
+		{"struct Point { x, y, fn scale(n) { Point(self.x * n, self.y * n); } } Point(1, 2).scale(3).y;", 6},
+		{"struct Counter { n, fn inc() { self.n = self.n + 1; self; } } let c = Counter(0); c.inc().inc(); c.n;", 2},
+		{"struct Point { x, fn get() { self.x; } } let f = Point(4).get; f();", 4},
+		{"struct Box { f } Box(fn(x) { x * 2; }).f(5);", 10},
+		{"struct N { v, fn down(k) { k == 0 ? self.v : down(k - 1); } } N(7).down(2);", 7},
+		{"struct N { v, fn sum(k) { k == 0 ? 0 : self.v + sum(k - 1); } } let f = N(2).sum; f(3);", 6},
+		{"struct N { v, fn down(k) { k == 0 ? self.v : down(k - 1); } } let a = N(1); let b = N(2); [a.down(1), b.down(1)][1];", 2},
+		{"[1, 2, 3].len();", 3},
+		{"[1].push(2).push(3).len();", 3},
+		{"[1, 2, 3].rest().first();", 2},
+		{`"hello".len();`, 5},
+		{`"42".int() + 1;`, 43},
+		{`{"a": 1, "b": 2}.len();`, 2},
+		{`let h = {}; h.add("a", 1); h["a"];`, 1},
+		{"[1, 2].type();", "ARRAY"},
+		{"[1, 2].map();", "unknown method map for ARRAY"},
+		{"[1, 2].push();", "wrong number of arguments. got=1, want=2"},
+		{"let n = 1; n.len();", "field access not supported: INTEGER"},
+		{"struct Point { x } Point(1).norm();", "unknown field or method norm for struct Point"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("wrong value for %q. expected=%q, got=%q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, obj.Message)
+				}
+			default:
+				t.Errorf("unexpected object for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestMethodInspectAndTrace(t *testing.T) {
+	evaluated := testEval("struct Point { x, fn get() { self.x; } } Point(1).get;")
+	if evaluated.Inspect() != "<fn Point.get/0>" {
+		t.Errorf("wrong Inspect. got=%q", evaluated.Inspect())
+	}
+
+	evaluated = testEval("struct Point { x, fn bad() {\n self.x + true;\n} }\nPoint(1).bad();")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(errObj.Trace) != 1 || errObj.Trace[0] != "Point.bad on line 2" {
+		t.Errorf("wrong trace. got=%q", errObj.Trace)
+	}
+}
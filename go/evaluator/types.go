@@ -0,0 +1,69 @@
+package evaluator
+
+import (
+	"lang/object"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func convertToInteger(obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Integer, *object.BigInt:
+		return obj
+	case *object.Float:
+		return floatToInteger(obj.Value)
+	case *object.Boolean:
+		if obj.Value {
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: 0}
+	case *object.String:
+		integer, ok := new(big.Int).SetString(strings.TrimSpace(obj.Value), 10)
+		if !ok {
+			return newError("could not convert %q to INTEGER", obj.Value)
+		}
+		return newBigInteger(integer)
+	default:
+		return newError("argument to `int` not supported, got %s", obj.Type())
+	}
+}
+
+func convertToFloat(obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Integer, *object.BigInt, *object.Float:
+		return &object.Float{Value: getFloatNumber(obj)}
+	case *object.Boolean:
+		if obj.Value {
+			return &object.Float{Value: 1}
+		}
+		return &object.Float{Value: 0}
+	case *object.String:
+		value, err := strconv.ParseFloat(strings.TrimSpace(obj.Value), 64)
+		if err != nil {
+			return newError("could not convert %q to FLOAT", obj.Value)
+		}
+		return &object.Float{Value: value}
+	default:
+		return newError("argument to `float` not supported, got %s", obj.Type())
+	}
+}
+
+// newTypePredicate returns a builtin reporting whether its argument has one
+// of the given types.
+func newTypePredicate(types ...object.ObjectType) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1",
+					len(args))
+			}
+			for _, t := range types {
+				if args[0].Type() == t {
+					return TRUE
+				}
+			}
+			return FALSE
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"lang/object"
+	"testing"
+)
+
+func TestTypeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`type(1);`, "INTEGER"},
+		{`type(99999999999999999999);`, "BIGINT"},
+		{`type(1.5);`, "FLOAT"},
+		{`type("a");`, "STRING"},
+		{`type([]);`, "ARRAY"},
+		{`type({});`, "HASH"},
+		{`type(fn() {});`, "FUNCTION"},
+		{`type(len);`, "BUILTIN"},
+		{`type(if (false) { 1; });`, "NULL"},
+		{`str(12);`, "12"},
+		{`str([1, "a"]);`, "[1, a]"},
+		{`str("a");`, "a"},
+		{`int("42");`, 42},
+		{`int(" -7 ");`, -7},
+		{`int(true);`, 1},
+		{`float("2.5");`, 2.5},
+		{`float(false);`, 0.0},
+		{`bool(0);`, false},
+		{`bool("");`, true},
+		{`bool(if (false) { 1; });`, false},
+		{`is_array([]);`, true},
+		{`is_array({});`, false},
+		{`is_function(len);`, true},
+		{`is_function(fn(x) { x; });`, true},
+		{`struct P { x } is_function(P);`, true},
+		{`struct P { x } is_function(P(1));`, false},
+		{`is_number(1.5);`, true},
+		{`is_int(1.5);`, false},
+		{`is_null(if (false) { 1; });`, true},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+			}
+		}
+	}
+}
+
+func TestConversionErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`int("4x");`, `could not convert "4x" to INTEGER`},
+		{`int("1.5");`, `could not convert "1.5" to INTEGER`},
+		{`float("abc");`, `could not convert "abc" to FLOAT`},
+		{`int([]);`, "argument to `int` not supported, got ARRAY"},
+		{`float({});`, "argument to `float` not supported, got HASH"},
+		{`type(1, 2);`, "wrong number of arguments. got=2, want=1"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)",
+				evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
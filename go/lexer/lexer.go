@@ -1,26 +1,33 @@
 package lexer
 
 import (
+	"fmt"
 	"lang/token"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Lexer struct {
 	input        string
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char under examination
+	ch           rune // current char under examination
 	lineNumber   int
+	// open brace count of each string interpolation being lexed, innermost last
+	interpolations []int
 }
 
 func (l *Lexer) readChar() {
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
 }
 
 func New(input string) *Lexer {
@@ -41,19 +48,16 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.EQ, Literal: literal, Line: l.lineNumber}
+		} else if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: "=>", Line: l.lineNumber}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch, l.lineNumber)
 		}
 	case '"':
-		str, isErr := l.readString()
-		if isErr {
-			tok = newToken(token.ILLEGAL, l.ch, l.lineNumber)
-		} else {
-			tok.Type = token.STRING
-			tok.Literal = str
-			tok.Line = l.lineNumber
-		}
-
+		tok = l.readString(token.STRING, token.STRING_HEAD)
+	case '`':
+		tok = l.readRawString()
 	case '+':
 		tok = newToken(token.PLUS, l.ch, l.lineNumber)
 	case '-':
@@ -89,6 +93,8 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = newToken(token.GT, l.ch, l.lineNumber)
 		}
+	case '?':
+		tok = newToken(token.QUESTION, l.ch, l.lineNumber)
 	case ':':
 		tok = newToken(token.COLON, l.ch, l.lineNumber)
 	case ';':
@@ -100,13 +106,32 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch, l.lineNumber)
 	case '{':
+		if depth := len(l.interpolations); depth > 0 {
+			l.interpolations[depth-1] += 1
+		}
 		tok = newToken(token.LBRACE, l.ch, l.lineNumber)
 	case '}':
+		if depth := len(l.interpolations); depth > 0 {
+			if l.interpolations[depth-1] == 0 {
+				l.interpolations = l.interpolations[:depth-1]
+				tok = l.readString(token.STRING_TAIL, token.STRING_MIDDLE)
+				break
+			}
+			l.interpolations[depth-1] -= 1
+		}
 		tok = newToken(token.RBRACE, l.ch, l.lineNumber)
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch, l.lineNumber)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch, l.lineNumber)
+	case '.':
+		if strings.HasPrefix(l.input[l.position:], "...") {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "...", Line: l.lineNumber}
+		} else {
+			tok = newToken(token.DOT, l.ch, l.lineNumber)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Line = l.lineNumber
@@ -126,15 +151,18 @@ func (l *Lexer) NextToken() token.Token {
 			}
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch, l.lineNumber)
+			tok = newIllegal(fmt.Sprintf("unexpected character %q", l.ch), l.lineNumber)
 		}
 	}
 	l.readChar()
 	return tok
 }
-func newToken(tokenType token.TokenType, ch byte, lineNumber int) token.Token {
+func newToken(tokenType token.TokenType, ch rune, lineNumber int) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch), Line: lineNumber}
 }
+func newIllegal(message string, lineNumber int) token.Token {
+	return token.Token{Type: token.ILLEGAL, Literal: message, Line: lineNumber}
+}
 func (l *Lexer) readIdentifier() string {
 	position := l.position
 	if isLetter(l.ch) {
@@ -145,11 +173,12 @@ func (l *Lexer) readIdentifier() string {
 	}
 	return l.input[position:l.position]
 }
-func (l *Lexer) peekChar() byte {
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+		return ch
 	}
 }
 func (l *Lexer) skipWhitespace() {
@@ -160,11 +189,12 @@ func (l *Lexer) skipWhitespace() {
 		l.readChar()
 	}
 }
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
-func isLetterOrDigit(ch byte) bool {
-	return isLetter(ch) || isDigit(ch)
+func isLetterOrDigit(ch rune) bool {
+	return isLetter(ch) || isDigit(ch) || ch >= utf8.RuneSelf && unicode.IsDigit(ch)
 }
 func (l *Lexer) readNumber() string {
 	position := l.position
@@ -179,41 +209,138 @@ func (l *Lexer) readNumber() string {
 	}
 	return l.input[position:l.position]
 }
-func (l *Lexer) readString() (string, bool) {
-	position := l.position + 1
+
+// readString reads a double-quoted string and decodes its escape sequences.
+// On a malformed escape the rest of the string is still consumed, so lexing
+// resumes after the closing quote. Text ending at the closing quote becomes
+// an end token, text ending at an interpolation "${" becomes a head token and
+// the embedded expression is lexed as regular tokens up to its closing brace.
+func (l *Lexer) readString(end, head token.TokenType) token.Token {
+	line := l.lineNumber
+	var out strings.Builder
+	errMessage, errLine := "", 0
 	for {
 		l.readChar()
-		if l.ch == '\\' {
-			switch l.peekChar() {
-			case 'n':
-				l.input = l.input[:l.position] + "\n" + l.input[l.position+2:]
-			case 't':
-				l.input = l.input[:l.position] + "\t" + l.input[l.position+2:]
-			case '\\':
-				l.input = l.input[:l.position] + "\\" + l.input[l.position+2:]
-			case 'r':
-				l.input = l.input[:l.position] + "\r" + l.input[l.position+2:]
-			case 'v':
-				l.input = l.input[:l.position] + "\r" + l.input[l.position+2:]
-			case '"':
-				l.input = l.input[:l.position] + "\r" + l.input[l.position+2:]
-			case 'a':
-				l.input = l.input[:l.position] + "\r" + l.input[l.position+2:]
-			case 'b':
-				l.input = l.input[:l.position] + "\r" + l.input[l.position+2:]
-			case 'f':
-				l.input = l.input[:l.position] + "\r" + l.input[l.position+2:]
+		switch l.ch {
+		case 0:
+			return newIllegal("unterminated string literal", line)
+		case '"':
+			if errMessage != "" {
+				return newIllegal(errMessage, errLine)
+			}
+			return token.Token{Type: end, Literal: out.String(), Line: line}
+		case '$':
+			out.WriteRune(l.ch)
+			if l.peekChar() != '{' {
+				break
 			}
+			l.readChar()
+			l.interpolations = append(l.interpolations, 0)
+			if errMessage != "" {
+				return newIllegal(errMessage, errLine)
+			}
+			literal := strings.TrimSuffix(out.String(), "$")
+			return token.Token{Type: head, Literal: literal, Line: line}
+		case '\\':
+			ch, message := l.readEscape()
+			if message != "" && errMessage == "" {
+				errMessage, errLine = message, l.lineNumber
+			}
+			out.WriteRune(ch)
+		case '\n':
+			l.lineNumber += 1
+			out.WriteRune(l.ch)
+		default:
+			out.WriteRune(l.ch)
 		}
-		if l.ch == 0 {
-			return "", true
+	}
+}
+
+// readRawString reads a backtick string, which has no escape sequences and
+// may span multiple lines.
+func (l *Lexer) readRawString() token.Token {
+	line := l.lineNumber
+	position := l.position + 1
+	for {
+		l.readChar()
+		switch l.ch {
+		case 0:
+			return newIllegal("unterminated raw string literal", line)
+		case '`':
+			return token.Token{Type: token.STRING, Literal: l.input[position:l.position], Line: line}
+		case '\n':
+			l.lineNumber += 1
 		}
-		if l.ch == '"' {
-			break
+	}
+}
+
+// readEscape decodes the escape sequence starting at the current backslash
+// and leaves l.ch on its last character. A non-empty message reports an
+// invalid sequence.
+func (l *Lexer) readEscape() (rune, string) {
+	if l.peekChar() == 0 {
+		return 0, ""
+	}
+	l.readChar()
+	switch l.ch {
+	case 'n':
+		return '\n', ""
+	case 't':
+		return '\t', ""
+	case 'r':
+		return '\r', ""
+	case 'v':
+		return '\v', ""
+	case 'a':
+		return '\a', ""
+	case 'b':
+		return '\b', ""
+	case 'f':
+		return '\f', ""
+	case '0':
+		return 0, ""
+	case '\\', '"', '\'', '$':
+		return l.ch, ""
+	case 'x':
+		// \xHH denotes the code point U+00HH
+		digits := l.readHexDigits(2)
+		if len(digits) != 2 {
+			return utf8.RuneError, "invalid escape sequence \\x" + digits + ": expected two hex digits"
 		}
+		value, _ := strconv.ParseUint(digits, 16, 8)
+		return rune(value), ""
+	case 'u':
+		if l.peekChar() != '{' {
+			return utf8.RuneError, "invalid escape sequence \\u: expected \\u{hex digits}"
+		}
+		l.readChar()
+		digits := l.readHexDigits(6)
+		if len(digits) == 0 || l.peekChar() != '}' {
+			return utf8.RuneError, "invalid escape sequence \\u{" + digits + ": expected \\u{hex digits}"
+		}
+		l.readChar()
+		value, _ := strconv.ParseUint(digits, 16, 32)
+		if !utf8.ValidRune(rune(value)) {
+			return utf8.RuneError, fmt.Sprintf("invalid code point U+%X in escape sequence", value)
+		}
+		return rune(value), ""
+	case '\n':
+		l.lineNumber += 1
 	}
-	return l.input[position:l.position], false
+	return utf8.RuneError, fmt.Sprintf("invalid escape sequence \\%c", l.ch)
 }
-func isDigit(ch byte) bool {
+
+// readHexDigits reads up to max hex digits following the current char.
+func (l *Lexer) readHexDigits(max int) string {
+	position := l.readPosition
+	for i := 0; i < max && isHexDigit(l.peekChar()); i++ {
+		l.readChar()
+	}
+	return l.input[position:l.readPosition]
+}
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
@@ -34,6 +34,10 @@ func TestNextToken(t *testing.T) {
 		while(true) { x + y; }
 		for(let i=0;i<3;i=i+1){}
 		break;
+		fn(...rest)
+		match (x) { _ => 1 }
+		a ? b : c
+		struct P { x } p.x
 	`
 	tests := []struct {
 		expectedType    token.TokenType
@@ -176,7 +180,34 @@ func TestNextToken(t *testing.T) {
 		{token.RBRACE, "}", 27},
 		{token.BREAK, "break", 28},
 		{token.SEMICOLON, ";", 28},
-		{token.EOF, "", 29},
+		{token.FUNCTION, "fn", 29},
+		{token.LPAREN, "(", 29},
+		{token.ELLIPSIS, "...", 29},
+		{token.IDENT, "rest", 29},
+		{token.RPAREN, ")", 29},
+		{token.MATCH, "match", 30},
+		{token.LPAREN, "(", 30},
+		{token.IDENT, "x", 30},
+		{token.RPAREN, ")", 30},
+		{token.LBRACE, "{", 30},
+		{token.IDENT, "_", 30},
+		{token.ARROW, "=>", 30},
+		{token.INT, "1", 30},
+		{token.RBRACE, "}", 30},
+		{token.IDENT, "a", 31},
+		{token.QUESTION, "?", 31},
+		{token.IDENT, "b", 31},
+		{token.COLON, ":", 31},
+		{token.IDENT, "c", 31},
+		{token.STRUCT, "struct", 32},
+		{token.IDENT, "P", 32},
+		{token.LBRACE, "{", 32},
+		{token.IDENT, "x", 32},
+		{token.RBRACE, "}", 32},
+		{token.IDENT, "p", 32},
+		{token.DOT, ".", 32},
+		{token.IDENT, "x", 32},
+		{token.EOF, "", 33},
 	}
 	l := New(input)
 	for i, tt := range tests {
@@ -195,3 +226,120 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestUnicode(t *testing.T) {
+	input := `let größe = "héllo";
+		let 名前 = "\u{65E5}\u{1F600}";
+		"bad \u{110000}";`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{token.LET, "let", 1},
+		{token.IDENT, "größe", 1},
+		{token.ASSIGN, "=", 1},
+		{token.STRING, "héllo", 1},
+		{token.SEMICOLON, ";", 1},
+		{token.LET, "let", 2},
+		{token.IDENT, "名前", 2},
+		{token.ASSIGN, "=", 2},
+		{token.STRING, "日😀", 2},
+		{token.SEMICOLON, ";", 2},
+		{token.ILLEGAL, "invalid code point U+110000 in escape sequence", 3},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line number wrong. expected=%v, got=%v",
+				i, tt.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	input := "\"a\\nb\\tc\\\\d\\\"e\\'f\\0\\x41\\u{e9}\\v\\a\\b\\f\\r\"\n" +
+		"`raw \\n\n\"string\"`\n" +
+		"\"multi\nline\" \"bad \\q\" x\n" +
+		"\"bad \\x4\"\n" +
+		"\"unterminated"
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{token.STRING, "a\nb\tc\\d\"e'f\x00Aé\v\a\b\f\r", 1},
+		{token.STRING, "raw \\n\n\"string\"", 2},
+		{token.STRING, "multi\nline", 4},
+		{token.ILLEGAL, "invalid escape sequence \\q", 5},
+		{token.IDENT, "x", 5},
+		{token.ILLEGAL, "invalid escape sequence \\x4: expected two hex digits", 6},
+		{token.ILLEGAL, "unterminated string literal", 7},
+		{token.EOF, "", 7},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line number wrong. expected=%v, got=%v",
+				i, tt.expectedLine, tok.Line)
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"sum ${a + b} of ${ {"k": "${x}"}["k"] }!" "\${a}" "$5"`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING_HEAD, "sum "},
+		{token.IDENT, "a"},
+		{token.PLUS, "+"},
+		{token.IDENT, "b"},
+		{token.STRING_MIDDLE, " of "},
+		{token.LBRACE, "{"},
+		{token.STRING, "k"},
+		{token.COLON, ":"},
+		{token.STRING_HEAD, ""},
+		{token.IDENT, "x"},
+		{token.STRING_TAIL, ""},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "k"},
+		{token.RBRACKET, "]"},
+		{token.STRING_TAIL, "!"},
+		{token.STRING, "${a}"},
+		{token.STRING, "$5"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"lang/ast"
 	"lang/evaluator"
 	"lang/lexer"
 	"lang/object"
@@ -11,20 +12,41 @@ import (
 	"lang/repl"
 	"os"
 	"os/user"
+	"strings"
 )
 
 func main() {
-	fileFlag := flag.String("f", "", "File path")
-	flag.Parse()
+	fileFlag := flag.String("f", "", "File path (use - to read from standard input)")
+	dumpASTFlag := flag.Bool("dump-ast", false, "Print the AST of the file as JSON instead of running it")
+	expandFlag := flag.Bool("expand", false, "Print the program after macro expansion (with -dump-ast: as JSON)")
+	var macroFiles []string
+	flag.Func("macros", "Load the macros of a library file (can be repeated)", func(path string) error {
+		macroFiles = append(macroFiles, path)
+		return nil
+	})
+	flags, scriptArgs := splitScriptArgs(os.Args[1:])
+	flag.CommandLine.Parse(flags)
 
 	if len(*fileFlag) > 0 {
-		if isValidFilePath(*fileFlag) {
-			HandleFileExecute(fileFlag)
-		} else {
+		if *fileFlag != "-" && !isValidFilePath(*fileFlag) {
 			fmt.Printf("File '%s' not found\n", *fileFlag)
 			os.Exit(1)
 		}
-		return
+		if *dumpASTFlag {
+			os.Exit(HandleDumpAST(fileFlag, *expandFlag, macroFiles))
+		}
+		if *expandFlag {
+			os.Exit(HandleExpand(fileFlag, macroFiles))
+		}
+		os.Exit(HandleFileExecute(fileFlag, append(flag.Args(), scriptArgs...), macroFiles))
+	}
+	if *dumpASTFlag || *expandFlag {
+		fmt.Println("-dump-ast and -expand require a file: -f \"file_name\"")
+		os.Exit(1)
+	}
+	if len(macroFiles) > 0 {
+		fmt.Println("-macros requires a file: -f \"file_name\"")
+		os.Exit(1)
 	}
 	// Check for other flags or arguments
 	otherFlags := flag.Args()
@@ -42,33 +64,191 @@ func main() {
 	repl.Start(os.Stdin, os.Stdout)
 }
 
-func HandleFileExecute(filePath *string) {
-	data, err := os.ReadFile(*filePath)
+// splitScriptArgs splits the command line after the script path given with
+// -f, so that arguments meant for the script are never parsed as interpreter
+// flags even when they start with '-'. A "--" right after the path is
+// dropped.
+func splitScriptArgs(args []string) (flags []string, scriptArgs []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		end := -1
+		switch {
+		case arg == "-f" || arg == "--f":
+			end = i + 2
+		case strings.HasPrefix(arg, "-f=") || strings.HasPrefix(arg, "--f="):
+			end = i + 1
+		}
+		if end < 0 || end > len(args) {
+			continue
+		}
+		scriptArgs = args[end:]
+		if len(scriptArgs) > 0 && scriptArgs[0] == "--" {
+			scriptArgs = scriptArgs[1:]
+		}
+		return args[:end], scriptArgs
+	}
+	return args, nil
+}
+
+// HandleFileExecute runs the script at filePath ("-" for standard input)
+// with the macros of macroFiles and returns the process exit status.
+func HandleFileExecute(filePath *string, args []string, macroFiles []string) int {
+	data, err := readSource(*filePath)
 	if err != nil {
-		return
+		fmt.Fprintf(os.Stderr, "Could not read '%s': %s\n", *filePath, err)
+		return 1
 	}
-	env := object.NewEnvironment()
-	macroEnv := object.NewEnvironment()
+	evaluator.SetInput(os.Stdin)
+	// The program runs in the environment of its macros, so that
+	// macroexpand can use them.
+	env, err := loadMacroFiles(macroFiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	env.Set("args", newArgsArray(args))
 	l := lexer.New(string(data))
 	p := parser.New(l)
 	program := p.ParseProgram()
 	if len(p.Errors()) != 0 {
-		printFileParserErrors(os.Stdout, p.Errors())
-		return
+		printFileParserErrors(os.Stderr, p.Errors())
+		return 1
 	}
-	evaluator.DefineMacros(program, macroEnv)
-	expanded := evaluator.ExpandMacros(program, macroEnv)
+	evaluator.DefineMacros(program, env)
+	expanded := evaluator.ExpandMacros(program, env)
 	evaluated := evaluator.Eval(expanded, env)
 	if evaluated != nil {
 		switch obj := evaluated.(type) {
 		case *object.Error:
 			printFileEvalError(obj)
+			return 1
 		case *object.Null:
 			break
 		default:
 			fmt.Println(evaluated.Inspect())
 		}
 	}
+	return 0
+}
+
+// HandleDumpAST prints the program at filePath as JSON, after macro expansion
+// if expand is set, and returns the process exit status.
+func HandleDumpAST(filePath *string, expand bool, macroFiles []string) int {
+	data, err := readSource(*filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read '%s': %s\n", *filePath, err)
+		return 1
+	}
+	l := lexer.New(string(data))
+	p := parser.New(l)
+	var program ast.Node = p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printFileParserErrors(os.Stderr, p.Errors())
+		return 1
+	}
+	if expand {
+		macroEnv, err := loadMacroFiles(macroFiles)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		evaluator.DefineMacros(program.(*ast.Program), macroEnv)
+		program = evaluator.ExpandMacros(program, macroEnv)
+	}
+	dump, err := ast.MarshalJSON(program, "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(dump))
+	return 0
+}
+
+// HandleExpand prints the program at filePath after macro expansion, one
+// top-level statement per line, and returns the process exit status.
+func HandleExpand(filePath *string, macroFiles []string) int {
+	data, err := readSource(*filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read '%s': %s\n", *filePath, err)
+		return 1
+	}
+	macroEnv, err := loadMacroFiles(macroFiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	l := lexer.New(string(data))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printFileParserErrors(os.Stderr, p.Errors())
+		return 1
+	}
+	evaluator.DefineMacros(program, macroEnv)
+	expanded := evaluator.ExpandMacros(program, macroEnv).(*ast.Program)
+	for _, line := range expandedLines(expanded) {
+		fmt.Println(line)
+	}
+	return 0
+}
+
+// expandedLines prints each statement of an expanded program as a line that
+// parses again, adding the semicolon an expression statement leaves out.
+func expandedLines(program *ast.Program) []string {
+	lines := []string{}
+	for _, statement := range program.Statements {
+		line := statement.String()
+		if _, ok := statement.(*ast.ExpressionStatement); ok && !strings.HasSuffix(line, ";") {
+			line += ";"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func loadMacroFiles(paths []string) (*object.Environment, error) {
+	macroEnv := object.NewEnvironment()
+	for _, path := range paths {
+		if err := loadMacroFile(path, macroEnv); err != nil {
+			return nil, err
+		}
+	}
+	return macroEnv, nil
+}
+
+// loadMacroFile parses the macro library at path and defines its macros in
+// macroEnv.
+func loadMacroFile(path string, macroEnv *object.Environment) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	p := parser.New(lexer.New(string(data)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return fmt.Errorf("%s: line %d: %s", path, p.Errors()[0].Line, p.Errors()[0].Message)
+	}
+	if err := evaluator.DefineMacroLibrary(program, macroEnv); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return nil
+}
+
+func readSource(filePath string) ([]byte, error) {
+	if filePath == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filePath)
+}
+
+func newArgsArray(args []string) *object.Array {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+	return &object.Array{Elements: elements}
 }
 
 func isValidFilePath(filePath string) bool {
@@ -77,7 +257,10 @@ func isValidFilePath(filePath string) bool {
 }
 
 func printFileEvalError(err *object.Error) {
-	fmt.Println("Error on line " + fmt.Sprintf("%v", err.Line) + ": " + err.Message)
+	fmt.Fprintln(os.Stderr, "Error on line "+fmt.Sprintf("%v", err.Line)+": "+err.Message)
+	for _, frame := range err.Trace {
+		fmt.Fprintln(os.Stderr, "\tin "+frame)
+	}
 }
 
 func printFileParserErrors(out io.Writer, errors []parser.ParseError) {
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"lang/ast"
+	"lang/evaluator"
+	"lang/lexer"
+	"lang/object"
+	"lang/parser"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitScriptArgs(t *testing.T) {
+	tests := []struct {
+		args       []string
+		flags      []string
+		scriptArgs []string
+	}{
+		{[]string{"-f", "a.mlg"}, []string{"-f", "a.mlg"}, nil},
+		{[]string{"-f", "a.mlg", "-x", "--verbose", "b"}, []string{"-f", "a.mlg"}, []string{"-x", "--verbose", "b"}},
+		{[]string{"-macros", "m.mlg", "-f=a.mlg", "-f", "x"}, []string{"-macros", "m.mlg", "-f=a.mlg"}, []string{"-f", "x"}},
+		{[]string{"-f", "a.mlg", "--", "-x"}, []string{"-f", "a.mlg"}, []string{"-x"}},
+		{[]string{"--", "-f", "a.mlg"}, []string{"--", "-f", "a.mlg"}, nil},
+		{[]string{"-dump-ast"}, []string{"-dump-ast"}, nil},
+	}
+	for _, tt := range tests {
+		flags, scriptArgs := splitScriptArgs(tt.args)
+		if !reflect.DeepEqual(flags, tt.flags) {
+			t.Errorf("wrong flags for %q. want=%q, got=%q", tt.args, tt.flags, flags)
+		}
+		if len(scriptArgs) != len(tt.scriptArgs) || len(scriptArgs) > 0 && !reflect.DeepEqual(scriptArgs, tt.scriptArgs) {
+			t.Errorf("wrong script args for %q. want=%q, got=%q", tt.args, tt.scriptArgs, scriptArgs)
+		}
+	}
+}
+
+func TestLoadMacroFiles(t *testing.T) {
+	dir := t.TempDir()
+	library := filepath.Join(dir, "lib.mk")
+	err := os.WriteFile(library, []byte(`
+		let unless = macro(c, a, b) { quote(if (!(unquote(c))) { unquote(a); } else { unquote(b); }); };
+	`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	macroEnv, err := loadMacroFiles([]string{library})
+	if err != nil {
+		t.Fatalf("loadMacroFiles failed: %s", err)
+	}
+	if macro, ok := macroEnv.Get("unless"); !ok || macro.Type() != object.MACRO_OBJ {
+		t.Errorf("macro unless not defined. got=%v", macro)
+	}
+
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{"let x = 1;", ": line 1: macro libraries may only contain macro definitions"},
+		{"let = 1;", ": line 1: expected next token to be IDENT, got = instead"},
+	}
+	for _, tt := range tests {
+		invalid := filepath.Join(dir, "invalid.mk")
+		if err := os.WriteFile(invalid, []byte(tt.source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err = loadMacroFiles([]string{invalid})
+		if err == nil || err.Error() != invalid+tt.expected {
+			t.Errorf("wrong error. want=%q, got=%v", invalid+tt.expected, err)
+		}
+	}
+	if _, err := loadMacroFiles([]string{filepath.Join(dir, "missing.mk")}); err == nil {
+		t.Errorf("expected error for a missing library")
+	}
+}
+
+func TestExpandedLinesRoundTrip(t *testing.T) {
+	input := `
+	let twice = macro(s) { quote(unquote(s) + unquote(s)); };
+	let x = "hi there";
+	let y = twice("say \"hi\"\\");
+	let z = twice("\${x} is ${x}");
+	let h = {"k": "v w"};
+	print(y, z, h["k"]);
+	`
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	env := object.NewEnvironment()
+	evaluator.DefineMacros(program, env)
+	expanded := evaluator.ExpandMacros(program, env).(*ast.Program)
+
+	lines := expandedLines(expanded)
+	if lines[0] != `let x = "hi there";` {
+		t.Errorf("string literal not quoted. got=%q", lines[0])
+	}
+	for _, line := range lines {
+		p := parser.New(lexer.New(line))
+		again := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Errorf("expansion %q does not parse: %v", line, p.Errors())
+			continue
+		}
+		if got := expandedLines(again); len(got) != 1 || got[0] != line {
+			t.Errorf("expansion does not round-trip. want=%q, got=%q", line, got)
+		}
+	}
+}
@@ -8,12 +8,13 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	return &Environment{store: s, consts: map[string]bool{}, outer: nil}
 }
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store  map[string]Object
+	consts map[string]bool
+	outer  *Environment
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -24,19 +25,15 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// GetEnv returns the innermost environment in which name is defined.
 func (e *Environment) GetEnv(name string) (*Environment, bool) {
-	_, ok := e.store[name]
-	var env *Environment
-	if ok {
-		env = e
+	if _, ok := e.store[name]; ok {
+		return e, true
 	}
-	if !ok && e.outer != nil {
-		_, ok = e.outer.Get(name)
-		if ok {
-			env = e.outer
-		}
+	if e.outer != nil {
+		return e.outer.GetEnv(name)
 	}
-	return env, ok
+	return nil, false
 }
 
 func (e *Environment) GetCurrScope(name string) (Object, bool) {
@@ -48,3 +45,14 @@ func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
 }
+
+// SetConst binds name like Set and marks it as constant in this scope.
+func (e *Environment) SetConst(name string, val Object) Object {
+	e.consts[name] = true
+	return e.Set(name, val)
+}
+
+// IsConst reports whether name is a constant of this scope.
+func (e *Environment) IsConst(name string) bool {
+	return e.consts[name]
+}
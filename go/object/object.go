@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"hash/fnv"
 	"lang/ast"
+	"math"
+	"math/big"
 	"strings"
 )
 
@@ -12,6 +14,7 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	BIGINT_OBJ       = "BIGINT"
 	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	STRING_OBJ       = "STRING"
@@ -24,6 +27,8 @@ const (
 	QUOTE_OBJ        = "QUOTE"
 	MACRO_OBJ        = "MACRO"
 	BREAK_OBJ        = "BREAK"
+	STRUCT_TYPE_OBJ  = "STRUCT_TYPE"
+	STRUCT_OBJ       = "STRUCT"
 	ERROR_OBJ        = "ERROR"
 )
 
@@ -39,12 +44,31 @@ type Integer struct {
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 
+// BigInt holds integers that do not fit in an int64. Arithmetic promotes
+// Integer results to BigInt on overflow and demotes them back when they fit.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (bi *BigInt) Type() ObjectType { return BIGINT_OBJ }
+func (bi *BigInt) Inspect() string  { return bi.Value.String() }
+
 type Float struct {
 	Value float64
 }
 
 func (i *Float) Type() ObjectType { return FLOAT_OBJ }
-func (i *Float) Inspect() string  { return fmt.Sprintf("%g", i.Value) }
+func (i *Float) Inspect() string {
+	switch {
+	case math.IsNaN(i.Value):
+		return "nan"
+	case math.IsInf(i.Value, 1):
+		return "inf"
+	case math.IsInf(i.Value, -1):
+		return "-inf"
+	}
+	return fmt.Sprintf("%g", i.Value)
+}
 
 type Boolean struct {
 	Value bool
@@ -68,31 +92,53 @@ func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 type Error struct {
 	Message string
 	Line    int
+	Trace   []string // the functions the error passed through, innermost first
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 
 type Function struct {
+	Name       string // empty for anonymous functions
 	Parameters []*ast.Identifier
+	Defaults   []ast.Expression
+	Rest       *ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
 func (f *Function) Inspect() string {
-	var out bytes.Buffer
-	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
+	if f.Name == "" {
+		return fmt.Sprintf("<fn/%s>", f.arity())
 	}
-	out.WriteString("fn")
-	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") {\n")
-	out.WriteString(f.Body.String())
-	out.WriteString("\n}")
-	return out.String()
+	return fmt.Sprintf("<fn %s/%s>", f.Name, f.arity())
+}
+
+// arity describes the accepted number of arguments: "2" for exactly two,
+// "1..2" when a parameter has a default value and "1+" with a rest parameter.
+func (f *Function) arity() string {
+	required := 0
+	for i := range f.Parameters {
+		if i >= len(f.Defaults) || f.Defaults[i] == nil {
+			required++
+		}
+	}
+	switch {
+	case f.Rest != nil:
+		return fmt.Sprintf("%d+", required)
+	case required < len(f.Parameters):
+		return fmt.Sprintf("%d..%d", required, len(f.Parameters))
+	}
+	return fmt.Sprint(required)
+}
+
+// DisplayName returns the name of the function for error messages.
+func (f *Function) DisplayName() string {
+	if f.Name == "" {
+		return "anonymous function"
+	}
+	return f.Name
 }
 
 type String struct {
@@ -104,6 +150,7 @@ func (s *String) Inspect() string  { return s.Value }
 
 type Array struct {
 	Elements []Object
+	Frozen   bool // set by `freeze`, rejects index assignment
 }
 
 func (ao *Array) Type() ObjectType { return ARRAY_OBJ }
@@ -136,7 +183,8 @@ type Hashable interface {
 	HashKey() HashKey
 }
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	Pairs  map[HashKey]HashPair
+	Frozen bool // set by `freeze`, rejects `add` and index assignment
 }
 
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
@@ -171,6 +219,26 @@ func (b *Boolean) HashKey() HashKey {
 func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
+func (bi *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(bi.Value.String()))
+	return HashKey{Type: bi.Type(), Value: h.Sum64()}
+}
+
+// HashKey of a whole Float equals the key of the equal Integer or BigInt, so
+// 1 and 1.0 address the same hash entry. All NaN values share one key.
+func (f *Float) HashKey() HashKey {
+	switch {
+	case math.IsNaN(f.Value):
+		return HashKey{Type: f.Type(), Value: math.Float64bits(math.NaN())}
+	case math.IsInf(f.Value, 0) || f.Value != math.Trunc(f.Value):
+		return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+	case f.Value >= math.MinInt64 && f.Value < math.MaxInt64:
+		return (&Integer{Value: int64(f.Value)}).HashKey()
+	}
+	integer, _ := big.NewFloat(f.Value).Int(nil)
+	return (&BigInt{Value: integer}).HashKey()
+}
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
@@ -190,6 +258,7 @@ type Macro struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Hygienic   bool
 }
 
 func (m *Macro) Type() ObjectType { return MACRO_OBJ }
@@ -201,6 +270,9 @@ func (m *Macro) Inspect() string {
 		params = append(params, p.String())
 	}
 
+	if m.Hygienic {
+		out.WriteString("hygienic ")
+	}
 	out.WriteString("macro")
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -217,3 +289,39 @@ type Break struct {
 
 func (b *Break) Type() ObjectType { return BREAK_OBJ }
 func (b *Break) Inspect() string  { return "break" }
+
+// StructType is declared by a struct statement. Calling it constructs a
+// Struct with the arguments as field values, in declaration order.
+type StructType struct {
+	Name    string
+	Fields  []string
+	Methods map[string]*Function
+}
+
+func (st *StructType) Type() ObjectType { return STRUCT_TYPE_OBJ }
+func (st *StructType) Inspect() string  { return "<struct " + st.Name + ">" }
+
+// HasField reports whether name is a field of the struct type.
+func (st *StructType) HasField(name string) bool {
+	for _, field := range st.Fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+type Struct struct {
+	StructType *StructType
+	Fields     map[string]Object
+	Frozen     bool // set by `freeze`, rejects field assignment
+}
+
+func (s *Struct) Type() ObjectType { return STRUCT_OBJ }
+func (s *Struct) Inspect() string {
+	fields := []string{}
+	for _, name := range s.StructType.Fields {
+		fields = append(fields, name+": "+s.Fields[name].Inspect())
+	}
+	return s.StructType.Name + "{" + strings.Join(fields, ", ") + "}"
+}
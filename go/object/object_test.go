@@ -1,6 +1,10 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"math/big"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -17,3 +21,37 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestBigIntHashKey(t *testing.T) {
+	big1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	big2, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	diff, _ := new(big.Int).SetString("123456789012345678901234567891", 10)
+	if (&BigInt{Value: big1}).HashKey() != (&BigInt{Value: big2}).HashKey() {
+		t.Errorf("big integers with same value have different hash keys")
+	}
+	if (&BigInt{Value: big1}).HashKey() == (&BigInt{Value: diff}).HashKey() {
+		t.Errorf("big integers with different values have same hash keys")
+	}
+}
+
+func TestFloatHashKey(t *testing.T) {
+	if (&Float{Value: 1.5}).HashKey() != (&Float{Value: 1.5}).HashKey() {
+		t.Errorf("floats with same value have different hash keys")
+	}
+	if (&Float{Value: 1.5}).HashKey() == (&Float{Value: 2.5}).HashKey() {
+		t.Errorf("floats with different values have same hash keys")
+	}
+	if (&Float{Value: 1}).HashKey() != (&Integer{Value: 1}).HashKey() {
+		t.Errorf("whole float and equal integer have different hash keys")
+	}
+	if (&Float{Value: math.Copysign(0, -1)}).HashKey() != (&Integer{Value: 0}).HashKey() {
+		t.Errorf("negative zero and zero have different hash keys")
+	}
+	big, _ := new(big.Int).SetString("100000000000000000000", 10)
+	if (&Float{Value: 1e20}).HashKey() != (&BigInt{Value: big}).HashKey() {
+		t.Errorf("whole float and equal big integer have different hash keys")
+	}
+	if (&Float{Value: math.NaN()}).HashKey() != (&Float{Value: -math.NaN()}).HashKey() {
+		t.Errorf("NaN values have different hash keys")
+	}
+}
@@ -1,16 +1,20 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"lang/ast"
 	"lang/lexer"
 	"lang/token"
+	"math/big"
+	"sort"
 	"strconv"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	CONDITIONAL // a ? b : c
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
@@ -22,6 +26,7 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
+	token.QUESTION: CONDITIONAL,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
@@ -35,11 +40,14 @@ var precedences = map[token.TokenType]int{
 	token.ASSIGN:   ASSIGN,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
 }
 
 type ParseError struct {
-	Message string
-	Line    int
+	Message  string
+	Line     int
+	Expected string      // what the parser was looking for, e.g. ";" or "expression"
+	Found    token.Token // the token it got instead
 }
 
 type Parser struct {
@@ -49,6 +57,22 @@ type Parser struct {
 	errors         []ParseError
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+	// panicking is set by the first error of a statement and suppresses the
+	// errors cascading from it until the parser resynchronizes.
+	panicking  bool
+	blockDepth int
+}
+
+// statementKeywords start a new statement, so they are safe points to
+// resume parsing after an error.
+var statementKeywords = map[token.TokenType]bool{
+	token.LET:    true,
+	token.CONST:  true,
+	token.RETURN: true,
+	token.WHILE:  true,
+	token.FOR:    true,
+	token.BREAK:  true,
+	token.STRUCT: true,
 }
 
 func New(l *lexer.Lexer) *Parser {
@@ -67,11 +91,14 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.STRING_HEAD, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
+	p.registerPrefix(token.HYGIENIC, p.parseHygienicMacroLiteral)
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -86,6 +113,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.QUESTION, p.parseConditionalExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
 	p.nextToken()
@@ -101,6 +130,36 @@ func (p *Parser) parseBoolean() ast.Expression {
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Parts = p.appendStringPart(str.Parts)
+	for {
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+		p.nextToken()
+		switch p.curToken.Type {
+		case token.STRING_MIDDLE:
+			str.Parts = p.appendStringPart(str.Parts)
+		case token.STRING_TAIL:
+			str.Parts = p.appendStringPart(str.Parts)
+			return str
+		case token.ILLEGAL:
+			p.addError(p.curToken.Literal, "}", p.curToken)
+			return nil
+		default:
+			msg := fmt.Sprintf("expected } to close string interpolation, got %s instead",
+				p.curToken.Type)
+			p.addError(msg, "}", p.curToken)
+			return nil
+		}
+	}
+}
+func (p *Parser) appendStringPart(parts []ast.Expression) []ast.Expression {
+	if p.curToken.Literal == "" {
+		return parts
+	}
+	return append(parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+}
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 	p.nextToken()
@@ -157,6 +216,19 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	expression.Consequence = p.parseBlockStatement()
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
+		if p.peekTokenIs(token.IF) {
+			// else if (...) {} is an alternative block holding just the
+			// nested if expression.
+			p.nextToken()
+			block := &ast.BlockStatement{Token: p.curToken}
+			nested := p.parseIfExpression()
+			if nested == nil {
+				return nil
+			}
+			block.Statements = []ast.Statement{&ast.ExpressionStatement{Token: block.Token, Expression: nested}}
+			expression.Alternative = block
+			return expression
+		}
 		if !p.expectPeek(token.LBRACE) {
 			return nil
 		}
@@ -165,6 +237,20 @@ func (p *Parser) parseIfExpression() ast.Expression {
 
 	return expression
 }
+
+// parseConditionalExpression parses the compact form cond ? a : b. It is
+// right associative, so a ? b : c ? d : e nests in the alternative.
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	expression := &ast.ConditionalExpression{Token: p.curToken, Condition: condition}
+	p.nextToken()
+	expression.Consequence = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+	p.nextToken()
+	expression.Alternative = p.parseExpression(LOWEST)
+	return expression
+}
 func (p *Parser) parseWhileStatement() ast.Statement {
 	statement := &ast.WhileStatement{Token: p.curToken}
 	if !p.expectPeek(token.LPAREN) {
@@ -214,9 +300,19 @@ func (p *Parser) parseForStatement() ast.Statement {
 	statement.Body = p.parseBlockStatement()
 	return statement
 }
+
+// parseArrayLiteral also accepts a trailing ...rest element when the array
+// is the pattern of a destructuring assignment, as in [a, ...rest] = arr.
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if n := len(array.Elements); n > 0 {
+		if rest, ok := array.Elements[n-1].(*ast.RestPattern); ok && !p.peekTokenIs(token.ASSIGN) {
+			msg := fmt.Sprintf("...%s is only allowed in a destructuring assignment", rest.Name.Value)
+			p.addError(msg, "=", p.peekToken)
+			return nil
+		}
+	}
 	return array
 }
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
@@ -226,48 +322,219 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 		return list
 	}
 	p.nextToken()
-	list = append(list, p.parseExpression(LOWEST))
+	list = append(list, p.parseListElement(end))
 	for p.peekTokenIs(token.COMMA) {
+		if _, ok := list[len(list)-1].(*ast.RestPattern); ok {
+			break
+		}
 		p.nextToken()
 		p.nextToken()
-		list = append(list, p.parseExpression(LOWEST))
+		list = append(list, p.parseListElement(end))
 	}
 	if !p.expectPeek(end) {
 		return nil
 	}
 	return list
 }
+
+// parseListElement parses one element of a list ending with end. Only
+// arrays, ending with ']', may end with a ...rest element.
+func (p *Parser) parseListElement(end token.TokenType) ast.Expression {
+	if end != token.RBRACKET || !p.curTokenIs(token.ELLIPSIS) {
+		return p.parseExpression(LOWEST)
+	}
+	rest := &ast.RestPattern{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	rest.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return rest
+}
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expression.Arms = []*ast.MatchArm{}
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := &ast.MatchArm{Pattern: p.parseMatchPattern()}
+		if arm.Pattern == nil {
+			return nil
+		}
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			p.nextToken()
+			arm.Guard = p.parseExpression(LOWEST)
+		}
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		arm.Token = p.curToken
+		p.nextToken()
+		arm.Body = p.parseExpression(LOWEST)
+		expression.Arms = append(expression.Arms, arm)
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	return expression
+}
+
+// parseMatchPattern parses the pattern of a match arm starting at the
+// current token. Unlike expressions, array patterns may end with ...rest and
+// hash patterns only take literal keys.
+func (p *Parser) parseMatchPattern() ast.Expression {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE:
+		return p.prefixParseFns[p.curToken.Type]()
+	case token.MINUS:
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			p.peekError(token.INT)
+			return nil
+		}
+		return p.parsePrefixExpression()
+	case token.LBRACKET:
+		array := &ast.ArrayLiteral{Token: p.curToken, Elements: []ast.Expression{}}
+		for !p.peekTokenIs(token.RBRACKET) {
+			p.nextToken()
+			if p.curTokenIs(token.ELLIPSIS) {
+				rest := &ast.RestPattern{Token: p.curToken}
+				if !p.expectPeek(token.IDENT) {
+					return nil
+				}
+				rest.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+				array.Elements = append(array.Elements, rest)
+				break
+			}
+			element := p.parseMatchPattern()
+			if element == nil {
+				return nil
+			}
+			array.Elements = append(array.Elements, element)
+			if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+		}
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return array
+	case token.LBRACE:
+		hash := &ast.HashLiteral{Token: p.curToken, Pairs: map[ast.Expression]ast.Expression{}}
+		for !p.peekTokenIs(token.RBRACE) {
+			p.nextToken()
+			key := p.parseMatchPattern()
+			if key == nil {
+				return nil
+			}
+			switch key.(type) {
+			case *ast.Identifier, *ast.ArrayLiteral, *ast.HashLiteral:
+				p.addError("hash pattern keys must be literals", "literal", p.curToken)
+				return nil
+			}
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+			p.nextToken()
+			value := p.parseMatchPattern()
+			if value == nil {
+				return nil
+			}
+			hash.Pairs[key] = value
+			if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+		}
+		if !p.expectPeek(token.RBRACE) {
+			return nil
+		}
+		return hash
+	default:
+		msg := fmt.Sprintf("unexpected %s in match pattern", p.curToken.Type)
+		p.addError(msg, "pattern", p.curToken)
+		return nil
+	}
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	lit := &ast.FunctionLiteral{Token: p.curToken}
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		lit.Name = p.curToken.Literal
+	}
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
-	lit.Parameters = p.parseFunctionParameters()
+	lit.Parameters, lit.Defaults, lit.Rest = p.parseFunctionParameters()
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 	lit.Body = p.parseBlockStatement()
 	return lit
 }
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+
+// parseFunctionParameters parses a parameter list like `(a, b = 2, ...rest)`.
+// defaults is nil when no parameter has a default value, otherwise it holds
+// one entry per parameter with nil for the required ones. Parameters with
+// defaults must come after the required ones and rest must be last.
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, []ast.Expression, *ast.Identifier) {
 	identifiers := []*ast.Identifier{}
+	var defaults []ast.Expression
+	var rest *ast.Identifier
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return identifiers, nil, nil
 	}
-	p.nextToken()
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
+	for {
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil, nil
+			}
+			rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil, nil, nil
+		}
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			if defaults == nil {
+				defaults = make([]ast.Expression, len(identifiers)-1)
+			}
+			defaults = append(defaults, p.parseExpression(LOWEST))
+		} else if defaults != nil {
+			msg := fmt.Sprintf("parameter %s needs a default value since it follows one with a default value",
+				ident.Value)
+			p.addError(msg, "=", p.peekToken)
+			return nil, nil, nil
+		}
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return nil, nil, nil
 	}
-	return identifiers
+	return identifiers, defaults, rest
 }
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
@@ -281,7 +548,47 @@ func (p *Parser) Errors() []ParseError {
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
 		t, p.peekToken.Type)
-	p.errors = append(p.errors, ParseError{Message: msg, Line: p.curToken.Line})
+	p.addError(msg, string(t), p.peekToken)
+}
+
+// addError records an error unless it cascades from an earlier error of the
+// same statement or repeats the previous one.
+func (p *Parser) addError(msg string, expected string, found token.Token) {
+	if p.panicking {
+		return
+	}
+	p.panicking = true
+	err := ParseError{Message: msg, Line: p.curToken.Line, Expected: expected, Found: found}
+	if n := len(p.errors); n > 0 && p.errors[n-1].Message == err.Message &&
+		p.errors[n-1].Line == err.Line {
+		return
+	}
+	p.errors = append(p.errors, err)
+}
+
+// synchronize skips the rest of a statement that failed to parse. It stops
+// on a ";", before a statement keyword, or before the "}" closing the block
+// being parsed, so the next statement gets its own diagnostics.
+func (p *Parser) synchronize() {
+	p.panicking = false
+	depth := 0
+	for !p.curTokenIs(token.EOF) && !p.peekTokenIs(token.EOF) {
+		switch p.curToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			depth--
+		}
+		if depth <= 0 {
+			if p.curTokenIs(token.SEMICOLON) || statementKeywords[p.peekToken.Type] {
+				return
+			}
+			if p.peekTokenIs(token.RBRACE) && p.blockDepth > 0 {
+				return
+			}
+		}
+		p.nextToken()
+	}
 }
 
 func (p *Parser) nextToken() {
@@ -293,7 +600,9 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 	for !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
-		if stmt != nil {
+		if p.panicking {
+			p.synchronize()
+		} else if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.nextToken()
@@ -303,7 +612,10 @@ func (p *Parser) ParseProgram() *ast.Program {
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
+		if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+			return p.parseDestructuringLetStatement()
+		}
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
@@ -313,6 +625,13 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseForStatement()
 	case token.BREAK:
 		return p.praseBreakStatement()
+	case token.STRUCT:
+		return p.parseStructStatement()
+	case token.FUNCTION:
+		if p.peekTokenIs(token.IDENT) {
+			return p.parseFunctionDeclaration()
+		}
+		return p.parseExpressionStatement()
 	default:
 		stmt := p.parseExpressionStatement()
 		if stmt == nil {
@@ -323,6 +642,212 @@ func (p *Parser) parseStatement() ast.Statement {
 
 }
 
+// parseFunctionDeclaration parses fn name() {} as a let statement binding
+// the named function literal to its name. A named function used as a value,
+// like fn name() {}(), is an expression statement instead.
+func (p *Parser) parseFunctionDeclaration() ast.Statement {
+	stmt := &ast.LetStatement{Token: p.curToken}
+	stmt.Name = &ast.Identifier{Token: p.peekToken, Value: p.peekToken.Literal}
+	lit, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+	if !ok {
+		return nil
+	}
+	if p.peekPrecedence() > LOWEST {
+		exprStmt := p.finishExpressionStatement(&ast.ExpressionStatement{Token: stmt.Token}, p.parseInfixExpressions(lit, LOWEST))
+		if exprStmt == nil {
+			return nil
+		}
+		return exprStmt
+	}
+	stmt.Value = lit
+	p.skipSemicolon()
+	return stmt
+}
+
+func (p *Parser) parseStructStatement() ast.Statement {
+	stmt := &ast.StructStatement{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Fields = []*ast.Identifier{}
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RBRACE) {
+		var kind, name string
+		if p.peekTokenIs(token.FUNCTION) {
+			p.nextToken()
+			if !p.peekTokenIs(token.IDENT) {
+				p.addError("struct methods must have a name", token.IDENT, p.peekToken)
+				return nil
+			}
+			method, ok := p.parseFunctionLiteral().(*ast.FunctionLiteral)
+			if !ok {
+				return nil
+			}
+			stmt.Methods = append(stmt.Methods, method)
+			kind, name = "method", method.Name
+		} else {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			stmt.Fields = append(stmt.Fields, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+			kind, name = "field", p.curToken.Literal
+		}
+		if seen[name] {
+			p.addError(fmt.Sprintf("duplicate %s %s in struct %s", kind, name, stmt.Name.Value),
+				"", p.curToken)
+			return nil
+		}
+		seen[name] = true
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	p.skipSemicolon()
+	return stmt
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	expression := &ast.MemberExpression{Token: p.curToken, Object: object}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expression.Member = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return expression
+}
+
+func (p *Parser) parseDestructuringLetStatement() ast.Statement {
+	stmt := &ast.DestructuringLetStatement{Token: p.curToken}
+	p.nextToken()
+	if p.curTokenIs(token.LBRACKET) {
+		pattern := &ast.ArrayPattern{Token: p.curToken}
+		pattern.Elements, pattern.Rest = p.parsePatternNames(token.RBRACKET)
+		if pattern.Elements == nil {
+			return nil
+		}
+		stmt.Pattern = pattern
+	} else {
+		pattern := p.parseHashPattern()
+		if pattern == nil {
+			return nil
+		}
+		stmt.Pattern = pattern
+	}
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.checkSemicolonError() {
+		return nil
+	}
+	return stmt
+}
+
+// parsePatternNames parses the names of a destructuring pattern up to the end
+// token. Only array patterns, ending with ']', may have a ...rest name.
+func (p *Parser) parsePatternNames(end token.TokenType) ([]*ast.Identifier, *ast.Identifier) {
+	names := []*ast.Identifier{}
+	var rest *ast.Identifier
+	seen := map[string]bool{}
+	for !p.peekTokenIs(end) {
+		if end == token.RBRACKET && p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil
+			}
+			rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.checkPatternName(seen, rest) {
+				return nil, nil
+			}
+		} else {
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil
+			}
+			name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.checkPatternName(seen, name) {
+				return nil, nil
+			}
+			names = append(names, name)
+		}
+		if rest != nil || !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(end) {
+		return nil, nil
+	}
+	return names, rest
+}
+
+// parseHashPattern parses {name, "key": other}. A bare name looks up the key
+// with the same name.
+func (p *Parser) parseHashPattern() *ast.HashPattern {
+	pattern := &ast.HashPattern{Token: p.curToken}
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RBRACE) {
+		var key *ast.StringLiteral
+		if p.peekTokenIs(token.STRING) {
+			p.nextToken()
+			key = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.expectPeek(token.COLON) {
+				return nil
+			}
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if key == nil {
+			tok := token.Token{Type: token.STRING, Literal: name.Value, Line: p.curToken.Line}
+			key = &ast.StringLiteral{Token: tok, Value: name.Value}
+		}
+		if !p.addHashPatternEntry(pattern, seen, key, name) {
+			return nil
+		}
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	return pattern
+}
+
+func (p *Parser) addHashPatternEntry(
+	pattern *ast.HashPattern,
+	seen map[string]bool,
+	key *ast.StringLiteral,
+	name *ast.Identifier,
+) bool {
+	if !p.checkPatternName(seen, name) {
+		return false
+	}
+	pattern.Keys = append(pattern.Keys, key)
+	pattern.Names = append(pattern.Names, name)
+	return true
+}
+
+// checkPatternName reports a name that is bound twice by one pattern.
+func (p *Parser) checkPatternName(seen map[string]bool, name *ast.Identifier) bool {
+	if seen[name.Value] {
+		p.addError(fmt.Sprintf("duplicate name %s in pattern", name.Value), "", p.curToken)
+		return false
+	}
+	seen[name.Value] = true
+	return true
+}
+
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 	if !p.expectPeek(token.IDENT) {
@@ -341,11 +866,95 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+// parseDestructuringAssignExpression turns the array or hash literal on the
+// left of [a, ...rest] = arr or {"x": x} = hash into the pattern a
+// destructuring let statement would have parsed.
+func (p *Parser) parseDestructuringAssignExpression(left ast.Expression) ast.Expression {
+	pattern := p.patternFromLiteral(left)
+	if pattern == nil {
+		return nil
+	}
+	expr := &ast.DestructuringAssignExpression{Token: p.curToken, Pattern: pattern}
+	p.nextToken()
+	expr.Value = p.parseExpression(LOWEST)
+	return expr
+}
+
+func (p *Parser) patternFromLiteral(left ast.Expression) ast.Expression {
+	seen := map[string]bool{}
+	switch left := left.(type) {
+	case *ast.ArrayLiteral:
+		pattern := &ast.ArrayPattern{Token: left.Token, Elements: []*ast.Identifier{}}
+		for _, element := range left.Elements {
+			if rest, ok := element.(*ast.RestPattern); ok {
+				if !p.checkPatternName(seen, rest.Name) {
+					return nil
+				}
+				pattern.Rest = rest.Name
+				continue
+			}
+			name := p.patternIdentifier(element)
+			if name == nil || !p.checkPatternName(seen, name) {
+				return nil
+			}
+			pattern.Elements = append(pattern.Elements, name)
+		}
+		return pattern
+	case *ast.HashLiteral:
+		pattern := &ast.HashPattern{Token: left.Token}
+		// Pairs is a map, sort the keys so the pattern has a stable order.
+		keys := make([]ast.Expression, 0, len(left.Pairs))
+		for key := range left.Pairs {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			str, ok := key.(*ast.StringLiteral)
+			if !ok {
+				p.addError(fmt.Sprintf("hash pattern keys must be strings. got=%q", key), token.STRING, p.curToken)
+				return nil
+			}
+			name := p.patternIdentifier(left.Pairs[key])
+			if name == nil || !p.addHashPatternEntry(pattern, seen, str, name) {
+				return nil
+			}
+		}
+		return pattern
+	}
+	return nil
+}
+
+func (p *Parser) patternIdentifier(element ast.Expression) *ast.Identifier {
+	name, ok := element.(*ast.Identifier)
+	if !ok {
+		msg := fmt.Sprintf("expected valid identifier. got=%q", element)
+		p.addError(msg, token.IDENT, p.curToken)
+		return nil
+	}
+	return name
+}
+
 func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	if index, ok := left.(*ast.IndexExpression); ok {
+		expr := &ast.IndexAssignExpression{Token: p.curToken, Left: index.Left, Index: index.Index}
+		p.nextToken()
+		expr.Value = p.parseExpression(LOWEST)
+		return expr
+	}
+	switch left.(type) {
+	case *ast.ArrayLiteral, *ast.HashLiteral:
+		return p.parseDestructuringAssignExpression(left)
+	}
+	if member, ok := left.(*ast.MemberExpression); ok {
+		expr := &ast.MemberAssignExpression{Token: p.curToken, Object: member.Object, Member: member.Member}
+		p.nextToken()
+		expr.Value = p.parseExpression(LOWEST)
+		return expr
+	}
 	name, ok := left.(*ast.Identifier)
 	if !ok {
 		msg := fmt.Sprintf("expected valid identifier. got=%q", left)
-		p.errors = append(p.errors, ParseError{Message: msg, Line: p.curToken.Line})
+		p.addError(msg, token.IDENT, p.curToken)
 		return nil
 	}
 	expr := &ast.AssignExpression{Token: p.curToken, Name: name}
@@ -374,8 +983,12 @@ func (p *Parser) praseBreakStatement() *ast.BreakStatement {
 }
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
-	expr := p.parseExpression(LOWEST)
+	return p.finishExpressionStatement(stmt, p.parseExpression(LOWEST))
+}
 
+// finishExpressionStatement sets the parsed expression of stmt and checks
+// the semicolon that has to follow it.
+func (p *Parser) finishExpressionStatement(stmt *ast.ExpressionStatement, expr ast.Expression) *ast.ExpressionStatement {
 	if expr == nil {
 		return nil
 	}
@@ -387,6 +1000,8 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 		p.skipSemicolon()
 	case "fn":
 		p.skipSemicolon()
+	case "match":
+		p.skipSemicolon()
 	default:
 		if p.checkSemicolonError() {
 			return nil
@@ -397,11 +1012,15 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
 
 	p.nextToken()
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
-		if stmt != nil {
+		if p.panicking {
+			p.synchronize()
+		} else if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 		p.nextToken()
@@ -415,7 +1034,12 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		p.noPrefixParseFnError(p.curToken.Type)
 		return nil
 	}
-	leftExp := prefix()
+	return p.parseInfixExpressions(prefix(), precedence)
+}
+
+// parseInfixExpressions continues an expression that starts with leftExp
+// with the infix operators binding tighter than precedence.
+func (p *Parser) parseInfixExpressions(leftExp ast.Expression, precedence int) ast.Expression {
 	for !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
@@ -463,8 +1087,11 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 		return false
 	}
 }
+
+// parseIllegal reports the lexer error carried by an ILLEGAL token and
+// returns a placeholder so the rest of the statement can still be parsed.
 func (p *Parser) parseIllegal() ast.Expression {
-	p.peekError("\"")
+	p.addError(p.curToken.Literal, "valid token", p.curToken)
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
@@ -483,9 +1110,14 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if bigValue, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			return &ast.BigIntegerLiteral{Token: p.curToken, Value: bigValue}
+		}
+	}
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, ParseError{Message: msg, Line: p.curToken.Line})
+		p.addError(msg, token.INT, p.curToken)
 		return nil
 	}
 	lit.Value = value
@@ -495,8 +1127,8 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	lit := &ast.FloatLiteral{Token: p.curToken}
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, ParseError{Message: msg, Line: p.curToken.Line})
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.addError(msg, token.FLOAT, p.curToken)
 		return nil
 	}
 	lit.Value = value
@@ -504,7 +1136,7 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 }
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, ParseError{Message: msg, Line: p.curToken.Line})
+	p.addError(msg, "expression", p.curToken)
 }
 
 func (p *Parser) parseMacroLiteral() ast.Expression {
@@ -514,7 +1146,13 @@ func (p *Parser) parseMacroLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	var defaults []ast.Expression
+	var rest *ast.Identifier
+	lit.Parameters, defaults, rest = p.parseFunctionParameters()
+	if defaults != nil || rest != nil {
+		p.addError("macro parameters can not have default values or be variadic", ")", p.curToken)
+		return nil
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
@@ -525,6 +1163,18 @@ func (p *Parser) parseMacroLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseHygienicMacroLiteral() ast.Expression {
+	if !p.expectPeek(token.MACRO) {
+		return nil
+	}
+	lit, ok := p.parseMacroLiteral().(*ast.MacroLiteral)
+	if !ok {
+		return nil
+	}
+	lit.Hygienic = true
+	return lit
+}
+
 func (p *Parser) skipSemicolon() {
 	for p.peekTokenIs((token.SEMICOLON)) {
 		p.nextToken()
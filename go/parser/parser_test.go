@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"lang/ast"
 	"lang/lexer"
+	"lang/token"
+	"strings"
 	"testing"
 )
 
@@ -165,6 +167,22 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	input := "99999999999999999999;"
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.BigIntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.BigIntegerLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value.String() != "99999999999999999999" {
+		t.Errorf("literal.Value not %s. got=%s", "99999999999999999999", literal.Value)
+	}
+}
+
 func TestFloatLiteralExpression(t *testing.T) {
 	input := "5.312;"
 	l := lexer.New(input)
@@ -193,6 +211,20 @@ func TestFloatLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralError(t *testing.T) {
+	input := "1" + strings.Repeat("0", 400) + ".5;"
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected parser errors")
+	}
+	err := p.Errors()[0]
+	if !strings.HasSuffix(err.Message, " as float") || err.Expected != token.FLOAT {
+		t.Errorf("wrong error. got=%q, expected=%q", err.Message, err.Expected)
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -319,6 +351,30 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-a * b;",
 			"((-a) * b)",
 		},
+		{
+			"a == b ? c + 1 : d;",
+			"((a == b) ? (c + 1) : d)",
+		},
+		{
+			"a ? b : c ? d : e;",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"x = a ? b : c;",
+			"x = (a ? b : c);",
+		},
+		{
+			"a.b.c;",
+			"((a.b).c)",
+		},
+		{
+			"-a.b * c.d[0];",
+			"((-(a.b)) * ((c.d)[0]))",
+		},
+		{
+			"a.b(1);",
+			"(a.b)(1)",
+		},
 		{
 			"!-a;",
 			"(!(-a))",
@@ -528,6 +584,70 @@ func TestIfElseExpression(t *testing.T) {
 		return
 	}
 }
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < y) { x; } else if (x > y) { y; } else { z; }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
+	}
+	if len(exp.Alternative.Statements) != 1 {
+		t.Fatalf("alternative is not 1 statement. got=%d", len(exp.Alternative.Statements))
+	}
+	alternative := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	nested, ok := alternative.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("alternative is not ast.IfExpression. got=%T", alternative.Expression)
+	}
+	if !testInfixExpression(t, nested.Condition, "x", ">", "y") {
+		return
+	}
+	if nested.Alternative == nil {
+		t.Fatalf("nested.Alternative is nil")
+	}
+	if program.String() != "if(x < y) xelse if(x > y) yelse z" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+
+	p = New(lexer.New(`if (x) { x; } else if { y; }`))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0].Message != "expected next token to be (, got { instead" {
+		t.Errorf("wrong errors for else if without condition. got=%v", p.Errors())
+	}
+}
+
+func TestConditionalExpression(t *testing.T) {
+	l := lexer.New("x > 0 ? x : -x;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.ConditionalExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.ConditionalExpression. got=%T", stmt.Expression)
+	}
+	testInfixExpression(t, exp.Condition, "x", ">", 0)
+	testIdentifier(t, exp.Consequence, "x")
+	if exp.Alternative.String() != "(-x)" {
+		t.Errorf("exp.Alternative wrong. got=%q", exp.Alternative.String())
+	}
+
+	p = New(lexer.New("a ? b;"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0].Message != "expected next token to be :, got ; instead" {
+		t.Errorf("wrong errors for missing ':'. got=%v", p.Errors())
+	}
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `fn(x, y) { x + y; }`
 	l := lexer.New(input)
@@ -590,6 +710,368 @@ func TestFunctionParameterParsing(t *testing.T) {
 		}
 	}
 }
+func TestDefaultAndRestParameterParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a, b = 2) {};", "fn(a, b = 2) "},
+		{"fn(a = 1, b = a + 1) {};", "fn(a = 1, b = (a + 1)) "},
+		{"fn(...rest) {};", "fn(...rest) "},
+		{"fn(a, b = [], ...rest) {};", "fn(a, b = [], ...rest) "},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function := stmt.Expression.(*ast.FunctionLiteral)
+		if function.String() != tt.expected {
+			t.Errorf("wrong function. want=%q, got=%q", tt.expected, function.String())
+		}
+	}
+
+	l := lexer.New("fn(a, b = 2, ...rest) {};")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(function.Defaults) != 2 || function.Defaults[0] != nil {
+		t.Fatalf("wrong defaults. got=%v", function.Defaults)
+	}
+	testIntegerLiteral(t, function.Defaults[1], 2)
+	testIdentifier(t, function.Rest, "rest")
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a = 1, b) {};", "parameter b needs a default value since it follows one with a default value"},
+		{"fn(...rest, a) {};", "expected next token to be ), got , instead"},
+		{"fn(1) {};", "expected next token to be IDENT, got INT instead"},
+		{"macro(a = 1) { a; };", "macro parameters can not have default values or be variadic"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestNamedFunctionParsing(t *testing.T) {
+	l := lexer.New(`fn add(a, b) { a + b; } let f = fn twice(x) { x * 2; };`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	decl, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.LetStatement. got=%T", program.Statements[0])
+	}
+	if decl.Token.Type != token.FUNCTION || decl.Name.Value != "add" {
+		t.Fatalf("wrong declaration. token=%q, name=%q", decl.Token.Type, decl.Name.Value)
+	}
+	function := decl.Value.(*ast.FunctionLiteral)
+	if function.Name != "add" {
+		t.Errorf("function.Name is not 'add'. got=%q", function.Name)
+	}
+	testLiteralExpression(t, function.Parameters[0], "a")
+	testLiteralExpression(t, function.Parameters[1], "b")
+	if decl.String() != "fn add(a, b) (a + b)" {
+		t.Errorf("decl.String() wrong. got=%q", decl.String())
+	}
+
+	let := program.Statements[1].(*ast.LetStatement)
+	function = let.Value.(*ast.FunctionLiteral)
+	if let.Name.Value != "f" || function.Name != "twice" {
+		t.Errorf("wrong names. let=%q, function=%q", let.Name.Value, function.Name)
+	}
+}
+
+func TestInvokedNamedFunctionParsing(t *testing.T) {
+	l := lexer.New(`fn f() { print(1); }(); fn g(x) { x; }(5) + 1;`)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{"fn f() print(1)()", "(fn g(x) x(5) + 1)"}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(expected), len(program.Statements))
+	}
+	for i, want := range expected {
+		stmt, ok := program.Statements[i].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not *ast.ExpressionStatement. got=%T", i, program.Statements[i])
+		}
+		if stmt.String() != want {
+			t.Errorf("stmt.String() wrong. want=%q, got=%q", want, stmt.String())
+		}
+	}
+}
+
+func TestDestructuringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = arr;", "let [a, b] = arr;"},
+		{"let [first, ...rest] = [1, 2, 3];", "let [first, ...rest] = [1, 2, 3];"},
+		{"let [...all] = arr;", "let [...all] = arr;"},
+		{"let [] = arr;", "let [] = arr;"},
+		{"const {name, age} = person;", `const {"name": name, "age": age} = person;`},
+		{"[a, b] = [b, a];", "[a, b] = [b, a];"},
+		{"[a, ...r] = [1, 2, 3];", "[a, ...r] = [1, 2, 3];"},
+		{"[...r] = arr;", "[...r] = arr;"},
+		{`{"x": x} = {"x": 5};`, `{"x": x} = {"x":5};`},
+		{`{"y": b, "x": a} = h;`, `{"x": a, "y": b} = h;`},
+		{`let {"full name": name, age} = person;`, `let {"full name": name, "age": age} = person;`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+				len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+		// The printed pattern parses again to the same statement.
+		p = New(lexer.New(program.String()))
+		again := p.ParseProgram()
+		checkParserErrors(t, p)
+		if again.String() != tt.expected {
+			t.Errorf("does not round-trip. expected=%q, got=%q", tt.expected, again.String())
+		}
+	}
+
+	l := lexer.New("let {name, age} = person;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt, ok := program.Statements[0].(*ast.DestructuringLetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.DestructuringLetStatement. got=%T", program.Statements[0])
+	}
+	pattern, ok := stmt.Pattern.(*ast.HashPattern)
+	if !ok {
+		t.Fatalf("stmt.Pattern is not *ast.HashPattern. got=%T", stmt.Pattern)
+	}
+	testIdentifier(t, pattern.Names[0], "name")
+	testIdentifier(t, pattern.Names[1], "age")
+	if pattern.Keys[0].Value != "name" || pattern.Keys[1].Value != "age" {
+		t.Errorf("wrong keys. got=%q, %q", pattern.Keys[0].Value, pattern.Keys[1].Value)
+	}
+	testIdentifier(t, stmt.Value, "person")
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, ...b, c] = arr;", "expected next token to be ], got , instead"},
+		{"let {a, ...b} = h;", "expected next token to be IDENT, got ... instead"},
+		{"let [a, a] = arr;", "duplicate name a in pattern"},
+		{"let [1] = arr;", "expected next token to be IDENT, got INT instead"},
+		{"[a, 1] = arr;", `expected valid identifier. got="1"`},
+		{"[a, a] = arr;", "duplicate name a in pattern"},
+		{"[a, ...a] = arr;", "duplicate name a in pattern"},
+		{"[a, ...r, b] = arr;", "expected next token to be ], got , instead"},
+		{"[1, ...r];", "...r is only allowed in a destructuring assignment"},
+		{"f(...r);", "no prefix parse function for ... found"},
+		{`{1: x} = h;`, `hash pattern keys must be strings. got="1"`},
+		{`{"x": 1} = h;`, `expected valid identifier. got="1"`},
+		{`{"x": a, "y": a} = h;`, "duplicate name a in pattern"},
+		{`let {"x": a, a} = h;`, "duplicate name a in pattern"},
+		{`let {"x"} = h;`, "expected next token to be :, got } instead"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	input := `match (x) { 0 => "zero", -1 => "minus one", [a, ...rest] if a > 0 => a, {"k": v} => v, _ => x }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	match, ok := stmt.Expression.(*ast.MatchExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.MatchExpression. got=%T", stmt.Expression)
+	}
+	testIdentifier(t, match.Subject, "x")
+	if len(match.Arms) != 5 {
+		t.Fatalf("match.Arms does not contain 5 arms. got=%d", len(match.Arms))
+	}
+	testIntegerLiteral(t, match.Arms[0].Pattern, 0)
+	if match.Arms[0].Guard != nil {
+		t.Errorf("match.Arms[0].Guard is not nil. got=%s", match.Arms[0].Guard)
+	}
+	array, ok := match.Arms[2].Pattern.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("match.Arms[2].Pattern is not *ast.ArrayLiteral. got=%T", match.Arms[2].Pattern)
+	}
+	testIdentifier(t, array.Elements[0], "a")
+	rest, ok := array.Elements[1].(*ast.RestPattern)
+	if !ok {
+		t.Fatalf("array.Elements[1] is not *ast.RestPattern. got=%T", array.Elements[1])
+	}
+	testIdentifier(t, rest.Name, "rest")
+	testInfixExpression(t, match.Arms[2].Guard, "a", ">", 0)
+	testIdentifier(t, match.Arms[2].Body, "a")
+	testIdentifier(t, match.Arms[4].Pattern, "_")
+
+	expected := `match (x) { 0 => "zero", (-1) => "minus one", [a, ...rest] if (a > 0) => a, {"k":v} => v, _ => x }`
+	if match.String() != expected {
+		t.Errorf("match.String() wrong.\nexpected=%q\ngot=%q", expected, match.String())
+	}
+}
+
+func TestMatchPatternErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { a + 1 => 1 };", "expected next token to be =>, got + instead"},
+		{"match (x) { fn() {} => 1 };", "unexpected FUNCTION in match pattern"},
+		{"match (x) { [...a, b] => 1 };", "expected next token to be ], got , instead"},
+		{"match (x) { {a: 1} => 1 };", "hash pattern keys must be literals"},
+		{"match (x) { -a => 1 };", "expected next token to be INT, got IDENT instead"},
+		{"match (x) { 1 => 1 2 => 2 };", "expected next token to be ,, got INT instead"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
+func TestStructStatement(t *testing.T) {
+	l := lexer.New("struct Point { x, y } p.x = 3;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.StructStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.StructStatement. got=%T", program.Statements[0])
+	}
+	testIdentifier(t, stmt.Name, "Point")
+	if len(stmt.Fields) != 2 {
+		t.Fatalf("stmt.Fields does not contain 2 fields. got=%d", len(stmt.Fields))
+	}
+	testIdentifier(t, stmt.Fields[0], "x")
+	testIdentifier(t, stmt.Fields[1], "y")
+	if stmt.String() != "struct Point { x, y }" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+
+	exp := program.Statements[1].(*ast.ExpressionStatement)
+	assign, ok := exp.Expression.(*ast.MemberAssignExpression)
+	if !ok {
+		t.Fatalf("exp.Expression is not *ast.MemberAssignExpression. got=%T", exp.Expression)
+	}
+	testIdentifier(t, assign.Object, "p")
+	testIdentifier(t, assign.Member, "x")
+	testIntegerLiteral(t, assign.Value, 3)
+}
+
+func TestStructMethods(t *testing.T) {
+	l := lexer.New("struct Point { x, fn sum(n) { self.x + n; }, y }")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.StructStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.StructStatement. got=%T", program.Statements[0])
+	}
+	if len(stmt.Fields) != 2 || len(stmt.Methods) != 1 {
+		t.Fatalf("wrong members. fields=%d, methods=%d", len(stmt.Fields), len(stmt.Methods))
+	}
+	method := stmt.Methods[0]
+	if method.Name != "sum" {
+		t.Errorf("method.Name is not 'sum'. got=%q", method.Name)
+	}
+	testIdentifier(t, method.Parameters[0], "n")
+	if stmt.String() != "struct Point { x, y, fn sum(n) ((self.x) + n) }" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, x }", "duplicate field x in struct Point"},
+		{"struct { x }", "expected next token to be IDENT, got { instead"},
+		{"struct Point { 1 }", "expected next token to be IDENT, got INT instead"},
+		{"p.1;", "expected next token to be IDENT, got INT instead"},
+		{"struct P { fn() { 1; } }", "struct methods must have a name"},
+		{"struct P { x, fn x() { 1; } }", "duplicate method x in struct P"},
+		{"struct P { fn a() { 1; } fn b() { 2; } }", "expected next token to be ,, got FUNCTION instead"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, errors[0].Message)
+		}
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 	l := lexer.New(input)
@@ -765,6 +1247,66 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStringParsing(t *testing.T) {
+	input := `"sum is ${a + b}, ${c}";`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+	if len(str.Parts) != 4 {
+		t.Fatalf("len(str.Parts) not 4. got=%d", len(str.Parts))
+	}
+	testInfixExpression(t, str.Parts[1], "a", "+", "b")
+	testIdentifier(t, str.Parts[3], "c")
+	if str.String() != `"sum is ${(a + b)}, ${c}"` {
+		t.Errorf("str.String() wrong. got=%q", str.String())
+	}
+}
+
+func TestInterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"sum is ${a + b";`, ""},
+		{`"${1}\q";`, `invalid escape sequence \q`},
+		{`"${1} and ${2}\q";`, `invalid escape sequence \q`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Fatalf("expected parser errors for %q", tt.input)
+		}
+		if tt.expected != "" && p.Errors()[0].Message != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input,
+				tt.expected, p.Errors()[0].Message)
+		}
+	}
+}
+
+func TestInterpolatedStringRoundTrip(t *testing.T) {
+	input := `"say \"${name}\" \\ \${x} $y";`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	printed := program.String()
+	if printed != `"say \"${name}\" \\ \${x} $y"` {
+		t.Fatalf("program.String() wrong. got=%q", printed)
+	}
+	reparsed := New(lexer.New(printed + ";")).ParseProgram()
+	if reparsed.String() != printed {
+		t.Errorf("reparsed program differs. got=%q", reparsed.String())
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3];"
 	l := lexer.New(input)
@@ -853,7 +1395,7 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 			continue
 		}
 
-		expectedValue := expected[literal.String()]
+		expectedValue := expected[literal.Value]
 		testIntegerLiteral(t, value, expectedValue)
 	}
 }
@@ -969,9 +1511,9 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 			continue
 		}
 
-		testFunc, ok := tests[literal.String()]
+		testFunc, ok := tests[literal.Value]
 		if !ok {
-			t.Errorf("No test function for key %q found", literal.String())
+			t.Errorf("No test function for key %q found", literal.Value)
 			continue
 		}
 
@@ -1026,6 +1568,43 @@ func TestMacroLiteralParsing(t *testing.T) {
 	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
 
+func TestHygienicMacroLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		hygienic bool
+	}{
+		{`macro(x) { x; };`, false},
+		{`hygienic macro(x) { x; };`, true},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		macro, ok := stmt.Expression.(*ast.MacroLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.MacroLiteral. got=%T",
+				stmt.Expression)
+		}
+		if macro.Hygienic != tt.hygienic {
+			t.Errorf("macro.Hygienic wrong. want=%t, got=%t", tt.hygienic, macro.Hygienic)
+		}
+	}
+
+	l := lexer.New(`let m = hygienic fn(x) { x; };`)
+	p := New(l)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Fatalf("expected an error for hygienic without macro")
+	}
+	if p.Errors()[0].Message != "expected next token to be MACRO, got FUNCTION instead" {
+		t.Errorf("wrong error. got=%q", p.Errors()[0].Message)
+	}
+}
+
 func TestWhileStatement(t *testing.T) {
 	input := `while (x < y) { x; }`
 	l := lexer.New(input)
@@ -1058,6 +1637,44 @@ func TestWhileStatement(t *testing.T) {
 	}
 }
 
+func TestConstStatement(t *testing.T) {
+	l := lexer.New("const answer = 42;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("s not *ast.LetStatement. got=%T", program.Statements[0])
+	}
+	if stmt.Token.Type != token.CONST {
+		t.Errorf("stmt.Token.Type not CONST. got=%s", stmt.Token.Type)
+	}
+	if stmt.Name.Value != "answer" {
+		t.Errorf("stmt.Name.Value not 'answer'. got=%s", stmt.Name.Value)
+	}
+	testLiteralExpression(t, stmt.Value, 42)
+	if stmt.String() != "const answer = 42;" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func TestIndexAssignExpression(t *testing.T) {
+	l := lexer.New("a[1 + 1] = b;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	expr, ok := stmt.Expression.(*ast.IndexAssignExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexAssignExpression. got=%T", stmt.Expression)
+	}
+	testIdentifier(t, expr.Left, "a")
+	testInfixExpression(t, expr.Index, 1, "+", 1)
+	testIdentifier(t, expr.Value, "b")
+}
+
 func TestAssignStatement(t *testing.T) {
 	tests := []struct {
 		input              string
@@ -1206,3 +1823,204 @@ func TestBreakStatement(t *testing.T) {
 		t.Fatalf("bodyStatement is not 'break'.got=%s", bodyStmt.TokenLiteral())
 	}
 }
+
+func TestIllegalTokenErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedLine    int
+	}{
+		{"let a = 1;\nlet s = \"bad \\q\";", "invalid escape sequence \\q", 2},
+		{"let s = \"one\ntwo \\u{zz}\";", "invalid escape sequence \\u{: expected \\u{hex digits}", 2},
+		{"let s = `never closed;", "unterminated raw string literal", 1},
+		{"let a = @;", "unexpected character '@'", 1},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errors[0].Message)
+		}
+		if errors[0].Line != tt.expectedLine {
+			t.Errorf("wrong error line. expected=%d, got=%d",
+				tt.expectedLine, errors[0].Line)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedMessages []string
+		expectedLines    []int
+		statements       int
+	}{
+		{
+			"let = 5;\nlet y = 10;",
+			[]string{"expected next token to be IDENT, got = instead"},
+			[]int{1},
+			1,
+		},
+		{
+			"let x = 5 + ;\nlet y = ) 2;\nlet z = 3;",
+			[]string{
+				"no prefix parse function for ; found",
+				"no prefix parse function for ) found",
+			},
+			[]int{1, 2},
+			1,
+		},
+		{
+			"let x = 5\nlet y = 10;\nreturn y;",
+			[]string{"expected next token to be ;, got LET instead"},
+			[]int{1},
+			2,
+		},
+		{
+			"let f = fn(x) {\n let a = * 2;\n let b = ;\n x;\n};\nf(1);",
+			[]string{
+				"no prefix parse function for * found",
+				"no prefix parse function for ; found",
+			},
+			[]int{2, 3},
+			2,
+		},
+		{
+			"if (x) { let = 1; } let y = 2;",
+			[]string{"expected next token to be IDENT, got = instead"},
+			[]int{1},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedMessages) {
+			for _, err := range errors {
+				t.Logf("parser error: %q", err.Message)
+			}
+			t.Fatalf("wrong number of errors for %q. expected=%d, got=%d",
+				tt.input, len(tt.expectedMessages), len(errors))
+		}
+		for i, err := range errors {
+			if err.Message != tt.expectedMessages[i] {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					tt.expectedMessages[i], err.Message)
+			}
+			if err.Line != tt.expectedLines[i] {
+				t.Errorf("wrong error line. expected=%d, got=%d",
+					tt.expectedLines[i], err.Line)
+			}
+		}
+		if len(program.Statements) != tt.statements {
+			t.Errorf("wrong number of statements for %q. expected=%d, got=%d",
+				tt.input, tt.statements, len(program.Statements))
+		}
+	}
+}
+
+func TestErrorExpectedAndFound(t *testing.T) {
+	tests := []struct {
+		input        string
+		expected     string
+		foundType    token.TokenType
+		foundLiteral string
+	}{
+		{"let x = 5 6;", ";", token.INT, "6"},
+		{"let 1 = 5;", token.IDENT, token.INT, "1"},
+		{"let x = );", "expression", token.RPAREN, ")"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("expected 1 error for %q. got=%d", tt.input, len(errors))
+		}
+		if errors[0].Expected != tt.expected {
+			t.Errorf("wrong expected. expected=%q, got=%q", tt.expected, errors[0].Expected)
+		}
+		if errors[0].Found.Type != tt.foundType || errors[0].Found.Literal != tt.foundLiteral {
+			t.Errorf("wrong found token. expected=%s %q, got=%s %q",
+				tt.foundType, tt.foundLiteral, errors[0].Found.Type, errors[0].Found.Literal)
+		}
+	}
+}
+
+func TestASTJSONRoundTrip(t *testing.T) {
+	input := `
+		let a = -5 + 2 * 3;
+		let b = 99999999999999999999;
+		let c = [1.5, inf, nan, "s", "x ${a} y", true];
+		let d = {"one": 1, 2: !false};
+		let f = fn(x, y) { if (x < y) { return x; } else { y; } };
+		let g = fn(x, y = 2, ...z) { z; };
+		fn h(a) { h(a); }
+		let [p, ...q] = [1, 2];
+		const {r} = {"r": 1};
+		[p, s] = [s, p];
+		[p, ...s] = q;
+		{"x": p, "y": s} = d;
+		let {"k": w, z} = d;
+		if (p) { 1; } else if (q) { 2; } else { 3; }
+		let v = p ? q : r ? s : t;
+		struct S { a, b, fn c(d) { self.a + d; } }
+		S(1, 2).a.b = v;
+		match (p) { [1, ...t] if t => t, {"a": -2.5, "b": [u]} => u, _ => "none" }
+		let m = macro(q) { quote(unquote(q)); };
+		let h = hygienic macro(q) { quote(unquote(q)); };
+		f(a, b)[0];
+		while (a) { a = a - 1; break; }
+		const k = 1;
+		c[0] = k;
+		for (; a < 3; a = a + 1) { }
+	`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	data, err := ast.MarshalJSON(program, "")
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %s", err)
+	}
+	decoded, err := ast.UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON failed: %s", err)
+	}
+	again, err := ast.MarshalJSON(decoded, "")
+	if err != nil {
+		t.Fatalf("MarshalJSON of decoded program failed: %s", err)
+	}
+	if string(data) != string(again) {
+		t.Errorf("round trip changed the AST.\nbefore=%s\nafter=%s", data, again)
+	}
+	stmt := decoded.(*ast.Program).Statements[0].(*ast.LetStatement)
+	if stmt.Token.Line != 2 || stmt.Name.Value != "a" {
+		t.Errorf("wrong decoded let statement. got=%+v", stmt)
+	}
+}
+
+func TestASTJSONErrors(t *testing.T) {
+	tests := []string{
+		`{"kind": "Nope", "line": 1}`,
+		`{"kind": "LetStatement", "line": 1, "children": {"name": {"kind": "IntegerLiteral", "value": 1}}}`,
+		`{"kind": "IntegerLiteral", "line": 1, "value": "x"}`,
+		`not json`,
+	}
+	for _, input := range tests {
+		if _, err := ast.UnmarshalJSON([]byte(input)); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package repl
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"lang/evaluator"
@@ -13,16 +12,17 @@ import (
 const PROMPT = ">> "
 
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
+	reader := evaluator.SetInput(in)
+	evaluator.SetOutput(out)
+	// Macros are defined in the same environment as the variables, so that
+	// macroexpand can use them.
 	env := object.NewEnvironment()
-	macroEnv := object.NewEnvironment()
 	for {
 		fmt.Fprintf(out, "%v", PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		line, err := reader.ReadString('\n')
+		if err != nil && len(line) == 0 {
 			return
 		}
-		line := scanner.Text()
 		l := lexer.New(line)
 		p := parser.New(l)
 
@@ -31,8 +31,8 @@ func Start(in io.Reader, out io.Writer) {
 			printReplParserErrors(out, p.Errors())
 			continue
 		}
-		evaluator.DefineMacros(program, macroEnv)
-		expanded := evaluator.ExpandMacros(program, macroEnv)
+		evaluator.DefineMacros(program, env)
+		expanded := evaluator.ExpandMacros(program, env)
 
 		evaluated := evaluator.Eval(expanded, env)
 		if evaluated != nil {
@@ -9,13 +9,17 @@ type Token struct {
 }
 
 const (
-	ILLEGAL = "ILLEGAL"
+	ILLEGAL = "ILLEGAL" // Literal holds the lexer error message
 	EOF     = "EOF"
 	// Identifiers + literals
 	IDENT  = "IDENT" // add, foobar, x, y, ...
 	INT    = "INT"   // 1343456
 	FLOAT  = "FLOAT" // 134.3456
 	STRING = "STRING"
+	// Parts of an interpolated string: "head ${a} middle ${b} tail"
+	STRING_HEAD   = "STRING_HEAD"
+	STRING_MIDDLE = "STRING_MIDDLE"
+	STRING_TAIL   = "STRING_TAIL"
 	// Operators
 	ASSIGN   = "="
 	EQ       = "=="
@@ -31,6 +35,7 @@ const (
 	LTE      = "<="
 	// Delimiters
 	COLON     = ":"
+	QUESTION  = "?"
 	COMMA     = ","
 	SEMICOLON = ";"
 	LPAREN    = "("
@@ -39,9 +44,13 @@ const (
 	RBRACE    = "}"
 	LBRACKET  = "["
 	RBRACKET  = "]"
+	ELLIPSIS  = "..."
+	DOT       = "."
+	ARROW     = "=>"
 	// Keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
@@ -51,20 +60,29 @@ const (
 	WHILE    = "WHILE"
 	FOR      = "FOR"
 	BREAK    = "BREAK"
+	HYGIENIC = "HYGIENIC"
+	MATCH    = "MATCH"
+	STRUCT   = "STRUCT"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
-	"macro":  MACRO,
-	"while":  WHILE,
-	"for":    FOR,
-	"break":  BREAK,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"const":    CONST,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"macro":    MACRO,
+	"while":    WHILE,
+	"for":      FOR,
+	"break":    BREAK,
+	"hygienic": HYGIENIC,
+	"match":    MATCH,
+	"struct":   STRUCT,
+	"inf":      FLOAT,
+	"nan":      FLOAT,
 }
 
 func LookupIdent(ident string) TokenType {